@@ -0,0 +1,65 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/alerts.go
+// Operator alerts: logged always, posted to alerts.webhook_url when enabled
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+var alertClient = &http.Client{Timeout: 10 * time.Second}
+
+// sendAlert logs an alert for the pool and, if alerts are enabled, posts it
+// to the configured webhook in the background. The "text" field makes the
+// payload usable as-is by Slack and Discord style incoming webhooks.
+func sendAlert(p *Pool, event, msg string) {
+	p.log.Printf("ALERT %s: %s", event, msg)
+	if cfgFile == nil || !cfgFile.Alerts.Enabled || cfgFile.Alerts.WebhookURL == "" {
+		return
+	}
+	body, _ := json.Marshal(map[string]string{
+		"text":      "PDM [" + p.Spec.ID + "] " + msg,
+		"pool":      p.Spec.ID,
+		"event":     event,
+		"message":   msg,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	})
+	url := cfgFile.Alerts.WebhookURL
+	go func() {
+		resp, err := alertClient.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("Alert webhook error: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Alert webhook returned %s", resp.Status)
+		}
+	}()
+}
@@ -0,0 +1,227 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/backfill.go
+// Bulk telemetry backfill, optionally stepping the backfilled dates
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// maxBackfillRowErrors caps the row errors reported for a rejected backfill.
+const maxBackfillRowErrors = 20
+
+// backfillRow is one dated row of a JSON backfill.
+type backfillRow struct {
+	Date       string     `json:"date"`
+	Oi         float64    `json:"oi"`
+	V          float64    `json:"v"`
+	ObservedAt *time.Time `json:"observed_at"`
+}
+
+// backfillHandler stores many dated rows of telemetry at once, from a JSON
+// array or a CSV body, and with ?step=true replays the missed steps through
+// them. Rows are checked as POST /api/telemetry and the CSV reader check
+// them; a backfill with any invalid row stores nothing.
+func (p *Pool) backfillHandler(w http.ResponseWriter, r *http.Request) {
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	w = sw
+	defer func() { p.metrics.responded(sw.status) }()
+
+	store, cfg, body, submitter, ok := p.readSubmission(w, r, 4<<20)
+	if !ok {
+		return
+	}
+	step := r.URL.Query().Get("step") == "true"
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var subs []Submission
+	var rowErrs []string
+	if mediaType == "text/csv" {
+		subs, rowErrs = p.backfillCSV(body, cfg)
+	} else {
+		var rows []backfillRow
+		if err := json.Unmarshal(body, &rows); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid JSON: expected an array of {date, oi, v} rows")
+			return
+		}
+		subs, rowErrs = p.backfillJSON(rows)
+	}
+	if len(rowErrs) > 0 {
+		if len(rowErrs) > maxBackfillRowErrors {
+			rowErrs = append(rowErrs[:maxBackfillRowErrors], fmt.Sprintf("and %d more", len(rowErrs)-maxBackfillRowErrors))
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "backfill rejected; nothing stored", "rows": rowErrs})
+		return
+	}
+	if len(subs) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "no rows")
+		return
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Date < subs[j].Date })
+	hash := payloadHash(body)
+	for i := range subs {
+		subs[i].PayloadHash = hash
+		if submitter != "" {
+			subs[i].Submitters = []string{submitter}
+		}
+	}
+
+	if err := store.SubmitAll(subs); err != nil {
+		p.log.Printf("Telemetry backfill write error: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to store telemetry")
+		return
+	}
+	p.log.Printf("Telemetry backfill stored %d row(s), %s to %s", len(subs), subs[0].Date, subs[len(subs)-1].Date)
+	p.metrics.submitted(len(subs))
+
+	resp := map[string]interface{}{
+		"status":     "stored",
+		"rows":       len(subs),
+		"first_date": subs[0].Date,
+		"last_date":  subs[len(subs)-1].Date,
+	}
+	if submitter != "" {
+		resp["submitter"] = submitter
+	}
+	if step {
+		resp["steps"], resp["skipped"] = p.stepBackfill(time.Now())
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// backfillJSON validates JSON rows as POST /api/telemetry validates one.
+func (p *Pool) backfillJSON(rows []backfillRow) ([]Submission, []string) {
+	loc := scheduleLocation(p.Spec.Schedule)
+	now := time.Now().UTC()
+	seen := make(map[string]int, len(rows))
+	var subs []Submission
+	var errs []string
+	for i, row := range rows {
+		fail := func(format string, args ...interface{}) {
+			errs = append(errs, fmt.Sprintf("row %d: ", i)+fmt.Sprintf(format, args...))
+		}
+		if _, err := time.ParseInLocation("2006-01-02", row.Date, loc); err != nil {
+			fail("date must be YYYY-MM-DD")
+			continue
+		}
+		if prev, dup := seen[row.Date]; dup {
+			fail("duplicate date %s (also row %d)", row.Date, prev)
+			continue
+		}
+		seen[row.Date] = i
+		if err := p.checkBackfillRow(row.Date, row.Oi, row.V); err != nil {
+			fail("%v", err)
+			continue
+		}
+		sub := Submission{Date: row.Date, Oi: row.Oi, V: row.V, ReceivedAt: now}
+		if row.ObservedAt != nil {
+			if row.ObservedAt.After(now) {
+				fail("observed_at is in the future")
+				continue
+			}
+			t := row.ObservedAt.UTC()
+			sub.ObservedAt = &t
+		}
+		subs = append(subs, sub)
+	}
+	return subs, errs
+}
+
+// backfillCSV reads a CSV body with the source's csv_columns and
+// csv_date_layout, as the CSV source reads its file.
+func (p *Pool) backfillCSV(body []byte, cfg TelemetryConfig) ([]Submission, []string) {
+	c := CSVTelemetry{loc: scheduleLocation(p.Spec.Schedule), cols: cfg.CSVColumns, layout: cfg.CSVDateLayout}
+	rows, unread, err := c.readRows(bytes.NewReader(body))
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+	var errs []string
+	for _, line := range unread {
+		errs = append(errs, fmt.Sprintf("line %d: missing column or unreadable date", line))
+	}
+	dates := make([]string, 0, len(rows))
+	for date := range rows {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	now := time.Now().UTC()
+	var subs []Submission
+	for _, date := range dates {
+		row := rows[date]
+		if row.err == nil {
+			row.err = p.checkBackfillRow(date, row.oi, row.v)
+		}
+		if row.err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: %v", row.line, row.err))
+			continue
+		}
+		subs = append(subs, Submission{Date: date, Oi: row.oi, V: row.v, ReceivedAt: now})
+	}
+	return subs, errs
+}
+
+// checkBackfillRow applies the checks every backfilled row must pass.
+func (p *Pool) checkBackfillRow(date string, oi, v float64) error {
+	if oi <= 0 {
+		return fmt.Errorf("Oi must be > 0")
+	}
+	if v < 0 {
+		return fmt.Errorf("V must be >= 0")
+	}
+	return p.checkSubmissionDate(date)
+}
+
+// stepBackfill replays every scheduled slot from the last chain entry up to
+// now, as catch_up: replay does, and returns the steps and skips recorded.
+func (p *Pool) stepBackfill(now time.Time) (steps, skipped int) {
+	p.stepMu.Lock()
+	defer p.stepMu.Unlock()
+
+	p.mu.RLock()
+	before := len(p.state.Journal)
+	p.mu.RUnlock()
+	p.catchUpWith(now, pdm.SkipPolicyReplay)
+
+	p.mu.RLock()
+	for _, e := range p.state.Journal[before:] {
+		switch {
+		case e.Step != nil:
+			steps++
+		case e.Skipped != nil:
+			skipped++
+		}
+	}
+	p.mu.RUnlock()
+	return steps, skipped
+}
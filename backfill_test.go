@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+func postBackfill(p *Pool, query, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/telemetry/backfill"+query, strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	p.backfillHandler(rec, r)
+	return rec
+}
+
+func TestBackfill_StepsHistoryFromGenesisDate(t *testing.T) {
+	p := testPool(t)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	day := func(n int) string { return today.AddDate(0, 0, n).Format("2006-01-02") }
+	p.Spec.GenesisDate = day(-3)
+	p.open()
+	sealed, err := os.ReadFile(filepath.Join(p.dataDir, genesisFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := postBackfill(p, "?step=true", "application/json", fmt.Sprintf(`[{"date":%q,"oi":1000000,"v":1}]`, day(-4))); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "before the pool's genesis") {
+		t.Fatalf("expected a date before genesis_date refused, got %d %s", rec.Code, rec.Body)
+	}
+
+	body := fmt.Sprintf(`[{"date":%q,"oi":1000000,"v":40000},{"date":%q,"oi":1000000,"v":50000},{"date":%q,"oi":1000000,"v":60000}]`, day(-1), day(-3), day(-2))
+	rec := postBackfill(p, "?step=true", "application/json", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("backfill: %d %s", rec.Code, rec.Body)
+	}
+	// Three backfilled dates stepped in date order; today's slot has no row.
+	j := p.state.Journal
+	if len(j) != 4 || j[0].Step == nil || j[0].Step.VTotal != 50000 || j[2].Step == nil || j[2].Step.VTotal != 40000 || j[3].Skipped == nil {
+		t.Fatalf("expected 3 steps then a skip, got %d entries: %s", len(j), rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `"steps":3`) {
+		t.Fatalf("unexpected response: %s", rec.Body)
+	}
+
+	if now, err := os.ReadFile(filepath.Join(p.dataDir, genesisFile)); err != nil || !bytes.Equal(now, sealed) {
+		t.Fatalf("backfill rewrote genesis.json: %v", err)
+	}
+	g, err := readGenesis(filepath.Join(p.dataDir, genesisFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := readJournal(filepath.Join(p.dataDir, journalFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.CreatedAt.Equal(today.AddDate(0, 0, -4)) {
+		t.Fatalf("genesis should be sealed at the slot before genesis_date, got %s", g.CreatedAt)
+	}
+	if rep := pdm.AuditFromGenesis(*g, entries); !rep.Valid {
+		t.Fatalf("backfilled chain failed audit: %+v", rep)
+	}
+
+	// Dates already on the chain are refused, and nothing is stored.
+	rec = postBackfill(p, "", "application/json", fmt.Sprintf(`[{"date":%q,"oi":1,"v":1},{"date":%q,"oi":1,"v":1}]`, day(-2), day(1)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "already recorded") {
+		t.Fatalf("expected recorded date refused, got %d %s", rec.Code, rec.Body)
+	}
+	if _, ok := p.manual.ForDate(day(1)); ok {
+		t.Fatal("a rejected backfill stored rows")
+	}
+}
+
+func TestBackfill_CSVAndRowValidation(t *testing.T) {
+	p := testPool(t)
+	p.open()
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1)
+	d1, d2 := tomorrow.Format("2006-01-02"), tomorrow.AddDate(0, 0, 1).Format("2006/01/02")
+
+	rec := postBackfill(p, "", "text/csv", "site,v,oi,date\nA,50000,1000000,"+d1+"\nA,60000,1100000,"+d2+"\n")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"rows":2`) {
+		t.Fatalf("CSV backfill: %d %s", rec.Code, rec.Body)
+	}
+	if sub, ok := p.manual.ForDate(tomorrow.AddDate(0, 0, 1).Format("2006-01-02")); !ok || sub.Oi != 1100000 {
+		t.Fatalf("CSV row not stored under its date: %+v", sub)
+	}
+
+	rec = postBackfill(p, "", "text/csv", "date,oi,v\n"+d1+",0,5\n"+d1+",1,1\nnot-a-date,1,1\n")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "duplicate rows") || !strings.Contains(rec.Body.String(), "line 4") {
+		t.Fatalf("expected duplicate and unreadable rows reported, got %d %s", rec.Code, rec.Body)
+	}
+	rec = postBackfill(p, "", "application/json", `[{"date":"`+d1+`","oi":-1,"v":1},{"date":"01/02/2026","oi":1,"v":1}]`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "row 0: Oi must be") || !strings.Contains(rec.Body.String(), "row 1: date must be YYYY-MM-DD") {
+		t.Fatalf("expected JSON row errors, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := postBackfill(p, "", "application/json", `[{"date":"2000-01-01","oi":1,"v":1}]`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "before the pool's genesis") {
+		t.Fatalf("expected a date before genesis refused, got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/catchup.go
+// Catch-up of scheduled steps missed while the server was down
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// lastScheduledTime is the time of the last step or skipped step, else the
+// genesis creation time; zero for a pool with neither. Callers hold p.mu.
+func (p *Pool) lastScheduledTime() time.Time {
+	for i := len(p.state.Journal) - 1; i >= 0; i-- {
+		if e := p.state.Journal[i]; e.Step != nil || e.Skipped != nil {
+			return e.Time()
+		}
+	}
+	if p.genesis != nil {
+		return p.genesis.CreatedAt
+	}
+	return time.Time{}
+}
+
+// scheduleSlots returns the scheduled run times t with after < t < before.
+func scheduleSlots(sched ScheduleConfig, after, before time.Time) []time.Time {
+	loc := scheduleLocation(sched)
+	runTime, err := time.Parse("15:04", sched.RunTime)
+	if err != nil {
+		runTime, _ = time.Parse("15:04", "00:00")
+	}
+
+	a := after.In(loc)
+	t := time.Date(a.Year(), a.Month(), a.Day(), runTime.Hour(), runTime.Minute(), 0, 0, loc)
+	if !t.After(after) {
+		t = t.AddDate(0, 0, 1)
+	}
+	var slots []time.Time
+	for t.Before(before) {
+		slots = append(slots, t)
+		t = t.AddDate(0, 0, 1)
+	}
+	return slots
+}
+
+// genesisTime returns when to seal a genesis whose first scheduled slot is
+// the one on date (YYYY-MM-DD in the schedule's time zone): a day before it.
+func genesisTime(sched ScheduleConfig, date string) (time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, scheduleLocation(sched))
+	if err != nil {
+		return time.Time{}, err
+	}
+	slots := scheduleSlots(sched, day.Add(-time.Nanosecond), day.AddDate(0, 0, 1))
+	if len(slots) == 0 {
+		return time.Time{}, fmt.Errorf("no scheduled slot on %s", date)
+	}
+	return slots[0].AddDate(0, 0, -1), nil
+}
+
+// catchUp accounts for every scheduled slot between the last chain entry and
+// `before` according to schedule.catch_up, so that each scheduled date is
+// either stepped or explicitly skipped on the chain.
+func (p *Pool) catchUp(before time.Time) {
+	policy := p.Spec.Schedule.CatchUp
+	if policy == "" {
+		policy = pdm.SkipPolicySkip
+	}
+	p.catchUpWith(before, policy)
+}
+
+// catchUpWith is catchUp under the given catch-up policy.
+func (p *Pool) catchUpWith(before time.Time, policy string) {
+	p.mu.RLock()
+	last := p.lastScheduledTime()
+	if q := p.quarantine; q != nil && q.ScheduledAt.After(last) {
+		// The quarantined slot is accounted for until the next step.
+		last = q.ScheduledAt
+	}
+	p.mu.RUnlock()
+	if last.IsZero() {
+		return
+	}
+	slots := scheduleSlots(p.Spec.Schedule, last, before)
+	if len(slots) == 0 {
+		return
+	}
+
+	p.log.Printf("Catching up %d missed step(s) since %s (policy %s)", len(slots), last.Format(time.RFC3339), policy)
+
+	latest := slots[len(slots)-1]
+	var combined []pdm.CombinedSlot
+	for _, at := range slots {
+		p.expireQuarantine(at)
+		switch {
+		case policy == pdm.SkipPolicyReplay:
+			oi, vtotal, info, err := p.fetchTelemetryValues(at)
+			if err != nil {
+				p.skip(at, policy, "replay: "+err.Error())
+				continue
+			}
+			// Nobody is waiting on a replayed slot, so values that fail
+			// the gate are skipped rather than quarantined.
+			if reasons := p.sanityCheck(oi, vtotal); len(reasons) > 0 {
+				p.skip(at, pdm.SkipPolicyQuarantine, "replay: failed sanity gate: "+strings.Join(reasons, "; "))
+				continue
+			}
+			p.step(at, oi, vtotal, info)
+		case policy == pdm.SkipPolicyCombined && at.Equal(latest):
+			p.runScheduledCombining(at, combined)
+		case policy == pdm.SkipPolicyCombined:
+			// The slot's V is carried into the step at latest; Oi is a
+			// level, so the latest slot's reading stands.
+			oi, v, _, err := p.fetchTelemetryValues(at)
+			if err != nil {
+				p.skip(at, policy, "no step recorded; no telemetry to combine: "+err.Error())
+				continue
+			}
+			if reasons := p.sanityCheck(oi, v); len(reasons) > 0 {
+				p.skip(at, pdm.SkipPolicyQuarantine, "combined: failed sanity gate: "+strings.Join(reasons, "; "))
+				continue
+			}
+			combined = append(combined, pdm.CombinedSlot{ScheduledAt: at.UTC(), V: v})
+			p.skip(at, policy, fmt.Sprintf("no step recorded; V=%g combined into step at %s", v, latest.Format(time.RFC3339)))
+		default:
+			p.skip(at, policy, "no step recorded at scheduled time (server down or step failed)")
+		}
+	}
+}
+
+// skip records the slot at `at` as a skipped step carrying the current supply.
+func (p *Pool) skip(at time.Time, policy, reason string) {
+	// A change dated on a skipped day still takes effect that day.
+	p.applyDueParamChanges(at)
+
+	p.mu.RLock()
+	sk := pdm.NewSkippedStep(p.headRoot(), at, policy, reason, p.state.S, p.state.MCap)
+	p.mu.RUnlock()
+
+	if err := p.commitEntry(pdm.Entry{Skipped: &sk}); err != nil {
+		p.log.Printf("ERROR: skipped step at %s not recorded: %v", at.Format(time.RFC3339), err)
+		return
+	}
+	p.log.Printf("Step at %s skipped (%s): %s", at.Format(time.RFC3339), policy, reason)
+}
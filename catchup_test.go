@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// testPoolWithGenesis returns a fresh pool sealed at created.
+func testPoolWithGenesis(t *testing.T, created time.Time) *Pool {
+	t.Helper()
+	p := testPool(t)
+	cfg := p.Spec.PDMConfig()
+	g := pdm.NewGenesis(p.Spec.Name, "units", p.Spec.MCap, p.Spec.InitialS, cfg, created)
+	p.genesis = &g
+	p.state = PoolState{S: p.Spec.InitialS, MCap: p.Spec.MCap, Config: cfg, GenesisRoot: g.HashChainRoot}
+	return p
+}
+
+func TestScheduleSlots(t *testing.T) {
+	sched := ScheduleConfig{RunTime: "06:00", Timezone: "UTC"}
+	after := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	slots := scheduleSlots(sched, after, time.Date(2026, 3, 4, 6, 0, 0, 0, time.UTC))
+	if len(slots) != 2 || !slots[0].Equal(after.AddDate(0, 0, 1)) {
+		t.Fatalf("expected the two slots strictly between, got %v", slots)
+	}
+}
+
+func TestCatchUp_SkipPolicyRecordsEachMissedSlot(t *testing.T) {
+	p := testPoolWithGenesis(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	p.Spec.Schedule.CatchUp = pdm.SkipPolicySkip
+
+	p.catchUp(time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+	if len(p.state.Journal) != 3 {
+		t.Fatalf("expected 3 skipped entries (Mar 2-4), got %d", len(p.state.Journal))
+	}
+	for _, e := range p.state.Journal {
+		if e.Skipped == nil || e.Skipped.S != p.Spec.InitialS {
+			t.Fatalf("expected skipped entry carrying initial supply, got %+v", e)
+		}
+	}
+	// A second call finds nothing left to do.
+	p.catchUp(time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+	if len(p.state.Journal) != 3 {
+		t.Fatalf("catch-up is not idempotent: %d entries", len(p.state.Journal))
+	}
+
+	entries, err := readJournal(filepath.Join(p.dataDir, journalFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, entries); !rep.Valid || rep.Chain.Skipped != 3 {
+		t.Fatalf("expected valid audit with 3 skips, got %+v", rep)
+	}
+}
+
+func TestCatchUp_ReplayUsesEachDatesTelemetry(t *testing.T) {
+	p := testPoolWithGenesis(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	p.Spec.Schedule.CatchUp = pdm.SkipPolicyReplay
+	p.Spec.Telemetry.Mode = "csv"
+	p.csv.csvPath = filepath.Join(t.TempDir(), "telemetry.csv")
+	os.WriteFile(p.csv.csvPath, []byte("date,oi,v\n2026-03-02,1000000,40000\n2026-03-04,1000000,60000\n"), 0644)
+
+	p.catchUp(time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+	j := p.state.Journal
+	if len(j) != 3 || j[0].Step == nil || j[1].Skipped == nil || j[2].Step == nil {
+		t.Fatalf("expected step, skip (no row for Mar 3), step; got %d entries", len(j))
+	}
+	if j[0].Step.VTotal != 40000 || j[2].Step.VTotal != 60000 {
+		t.Fatalf("replayed steps used wrong telemetry: %v, %v", j[0].Step.VTotal, j[2].Step.VTotal)
+	}
+	if prov := j[2].Step.Telemetry.Provenance; prov.Mode != "csv" || prov.Source != p.csv.csvPath+":3" || prov.PayloadHash != payloadHash([]byte("2026-03-04,1000000,60000")) {
+		t.Fatalf("expected the CSV row's provenance, got %+v", prov)
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, j); !rep.Valid {
+		t.Fatalf("replayed chain failed audit: %+v", rep)
+	}
+}
+
+func TestCatchUp_CombinedAddsMissedV(t *testing.T) {
+	p := testPoolWithGenesis(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	p.Spec.Schedule.CatchUp = pdm.SkipPolicyCombined
+	p.Spec.Telemetry.Mode = "csv"
+	p.csv.csvPath = filepath.Join(t.TempDir(), "telemetry.csv")
+	os.WriteFile(p.csv.csvPath, []byte("date,oi,v\n2026-03-02,900000,40000\n2026-03-04,1000000,60000\n"), 0644)
+
+	p.catchUp(time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+	j := p.state.Journal
+	if len(j) != 3 || j[0].Skipped == nil || j[1].Skipped == nil || j[2].Step == nil {
+		t.Fatalf("expected two skips then one step, got %d entries", len(j))
+	}
+	st := j[2].Step
+	if st.Oi != 1000000 || st.VTotal != 100000 {
+		t.Fatalf("expected the latest Oi and V summed over Mar 2 and 4, got Oi=%g V=%g", st.Oi, st.VTotal)
+	}
+	if c := st.Telemetry.Combined; len(c) != 1 || c[0].V != 40000 {
+		t.Fatalf("expected Mar 2 recorded as combined, got %+v", c)
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, j); !rep.Valid {
+		t.Fatalf("combined chain failed audit: %+v", rep)
+	}
+}
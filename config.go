@@ -29,28 +29,114 @@ package main
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"pdm-personal/pdm"
 )
 
 var cfgFile *ConfigFile
 
 type ConfigFile struct {
 	Pool      PoolConfig      `yaml:"pool"`
+	PDM       PDMParams       `yaml:"pdm"`
 	Resource  ResourceConfig  `yaml:"resource"`
 	Telemetry TelemetryConfig `yaml:"telemetry"`
 	Schedule  ScheduleConfig  `yaml:"schedule"`
+	Operator  OperatorConfig  `yaml:"operator"`
+	Pools     []PoolSpec      `yaml:"pools"`
 	Dashboard DashboardConfig `yaml:"dashboard"`
 	Alerts    AlertsConfig    `yaml:"alerts"`
 }
 
+// PoolSpec configures one pool of a multi-pool server. Sections a pool
+// omits (pdm, resource, telemetry, schedule, operator) are inherited whole from the
+// top-level sections. Without a pools: list, the top-level sections
+// describe a single pool with id "default" and data directory ./data.
+type PoolSpec struct {
+	PoolConfig `yaml:",inline"`
+	DataDir    string          `yaml:"data_dir"`
+	PDM        PDMParams       `yaml:"pdm"`
+	Resource   ResourceConfig  `yaml:"resource"`
+	Telemetry  TelemetryConfig `yaml:"telemetry"`
+	Schedule   ScheduleConfig  `yaml:"schedule"`
+	Operator   OperatorConfig  `yaml:"operator"`
+
+	// ID names the pool in routes (/pdm/v1/pools/{id}/...). It is the pool
+	// name for pools: entries and "default" for a single-pool config.
+	ID string `yaml:"-"`
+}
+
 type PoolConfig struct {
 	Name     string  `yaml:"name"`
 	MCap     float64 `yaml:"mcap"`
 	InitialS float64 `yaml:"initial_s"`
+
+	// GenesisDate (YYYY-MM-DD) dates a new pool's genesis record so that
+	// its first scheduled step falls on that day, letting past telemetry
+	// be backfilled and stepped. Empty seals the genesis at first start.
+	// It is ignored once the genesis exists.
+	GenesisDate string `yaml:"genesis_date"`
+}
+
+// PDMParams maps onto pdm.PDMConfig. Every field is optional; omitted
+// fields keep the value from pdm.DefaultConfig(pool.mcap). The same overlay
+// is the body of a parameter change submitted to /pdm/v1/params.
+type PDMParams struct {
+	PhiTarget     *float64 `yaml:"phi_target" json:"phi_target,omitempty"`
+	BandLow       *float64 `yaml:"band_low" json:"band_low,omitempty"`
+	BandHigh      *float64 `yaml:"band_high" json:"band_high,omitempty"`
+	BurnBase      *float64 `yaml:"burn_base" json:"burn_base,omitempty"`
+	BurnVelocityK *float64 `yaml:"burn_velocity_k" json:"burn_velocity_k,omitempty"`
+	MinS          *float64 `yaml:"min_s" json:"min_s,omitempty"`
+	MinO          *float64 `yaml:"min_o" json:"min_o,omitempty"`
+}
+
+// Apply overlays the configured parameters onto base.
+func (p PDMParams) Apply(base pdm.PDMConfig) pdm.PDMConfig {
+	set := func(dst *float64, src *float64) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	set(&base.PhiTarget, p.PhiTarget)
+	set(&base.BandLow, p.BandLow)
+	set(&base.BandHigh, p.BandHigh)
+	set(&base.BurnBase, p.BurnBase)
+	set(&base.BurnVelocityK, p.BurnVelocityK)
+	set(&base.MinS, p.MinS)
+	set(&base.MinO, p.MinO)
+	return base
+}
+
+// PDMConfig returns the control-law parameters configured for the pool.
+func (s PoolSpec) PDMConfig() pdm.PDMConfig {
+	return s.PDM.Apply(pdm.DefaultConfig(s.MCap))
+}
+
+// diffPDMConfig lists the parameters that differ between two configs, as
+// "name: a -> b" strings in PDMConfig field order.
+func diffPDMConfig(from, to pdm.PDMConfig) []string {
+	var out []string
+	cmp := func(name string, a, b float64) {
+		if a != b {
+			out = append(out, fmt.Sprintf("%s: %g -> %g", name, a, b))
+		}
+	}
+	cmp("phi_target", from.PhiTarget, to.PhiTarget)
+	cmp("band_low", from.BandLow, to.BandLow)
+	cmp("band_high", from.BandHigh, to.BandHigh)
+	cmp("burn_base", from.BurnBase, to.BurnBase)
+	cmp("burn_velocity_k", from.BurnVelocityK, to.BurnVelocityK)
+	cmp("min_s", from.MinS, to.MinS)
+	cmp("min_o", from.MinO, to.MinO)
+	return out
 }
 
 type ResourceConfig struct {
@@ -61,11 +147,181 @@ type TelemetryConfig struct {
 	Mode      string `yaml:"mode"`
 	CSVPath   string `yaml:"csv_path"`
 	AuthToken string `yaml:"auth_token"`
+	// OnFailure is what a scheduled step does when telemetry is missing or
+	// invalid: "skip" (default) records a skipped step, "hold" steps on the
+	// last good Oi and V, "abort" records a skip, alerts, and halts the pool.
+	OnFailure string `yaml:"on_failure"`
+	// MaxAge, a Go duration such as "36h", rejects submitted telemetry
+	// measured longer than this before the step it is used for. Empty
+	// disables the check. CSV rows are keyed by date and not aged.
+	MaxAge string `yaml:"max_age"`
+	// Aggregation is how repeated POSTs for one step combine: "replace"
+	// (default) keeps the latest, "accumulate" sums V increments and reads
+	// Oi as a gauge chosen by OiGauge: "last" (default), "max" or "twa"
+	// (time-weighted average over the window).
+	Aggregation string `yaml:"aggregation"`
+	OiGauge     string `yaml:"oi_gauge"`
+	// CSVColumns names the header columns holding the date, Oi and V
+	// (default date, oi, v; other columns are ignored). CSVDateLayout is an
+	// extra Go time layout for the date column, tried before the built-in
+	// ones.
+	CSVColumns    CSVColumns `yaml:"csv_columns"`
+	CSVDateLayout string     `yaml:"csv_date_layout"`
+	// Quorum configures the "quorum" mode, which aggregates several
+	// sources.
+	Quorum *QuorumConfig `yaml:"quorum"`
+	// Sanity, if set, gates telemetry before it is stepped. Values that fail
+	// are quarantined for operator confirmation instead of being applied.
+	Sanity *SanityConfig `yaml:"sanity"`
+	// HTTP configures the "http" mode, which pulls Oi and V from a JSON
+	// endpoint at step time.
+	HTTP *HTTPSourceConfig `yaml:"http"`
+	// Prometheus configures the "prometheus" mode, which runs PromQL
+	// instant queries at step time.
+	Prometheus *PrometheusSourceConfig `yaml:"prometheus"`
+	// Signing, if set, requires POST /api/telemetry to be HMAC-signed by one
+	// of its keys instead of carrying auth_token.
+	Signing *SigningConfig `yaml:"signing"`
+}
+
+// OperatorConfig is the credential for operator actions: confirming or
+// rejecting quarantined steps and scheduling parameter changes. It is kept
+// apart from the telemetry credentials so that a submitter cannot approve
+// its own values or retune the pool. Operator actions are refused unless
+// one is set; with both, a request must carry the token and be signed.
+type OperatorConfig struct {
+	AuthToken string         `yaml:"auth_token"`
+	Signing   *SigningConfig `yaml:"signing"`
+}
+
+// SigningConfig lists the submitters allowed to post signed telemetry.
+type SigningConfig struct {
+	// Keys maps each submitter's key id to its secret. Secrets may
+	// reference environment variables as ${NAME}.
+	Keys    map[string]string `yaml:"keys"`
+	MaxSkew string            `yaml:"max_skew"` // default "5m"
+}
+
+// MaxSkewDuration returns max_skew as a duration. It assumes the config has
+// been validated.
+func (s *SigningConfig) MaxSkewDuration() time.Duration {
+	d, _ := time.ParseDuration(s.MaxSkew)
+	return d
+}
+
+type QuorumConfig struct {
+	// Method is "median" (default): the median of the sources that report,
+	// provided at least MinSources do; or "agreement": at least K sources
+	// whose Oi and V agree within the relative Tolerance, using their
+	// median.
+	Method     string         `yaml:"method"`
+	MinSources int            `yaml:"min_sources"` // default: a majority
+	K          int            `yaml:"k"`           // default: a majority
+	Tolerance  float64        `yaml:"tolerance"`   // default 0.01
+	Sources    []QuorumSource `yaml:"sources"`
+}
+
+// QuorumSource is one member of a quorum. Its telemetry settings are those
+// of a pool's telemetry section; on_failure and sanity are taken from the
+// pool.
+type QuorumSource struct {
+	Name            string `yaml:"name"`
+	TelemetryConfig `yaml:",inline"`
+}
+
+type SanityConfig struct {
+	// Absolute bounds; unset bounds are not checked.
+	OiMin *float64 `yaml:"oi_min"`
+	OiMax *float64 `yaml:"oi_max"`
+	VMin  *float64 `yaml:"v_min"`
+	VMax  *float64 `yaml:"v_max"`
+	// MaxOiChange and MaxVChange bound the ratio to the previous step's
+	// value in either direction: 2 allows at most double or half. 0
+	// disables the check.
+	MaxOiChange float64 `yaml:"max_oi_change"`
+	MaxVChange  float64 `yaml:"max_v_change"`
+	// ZScore rejects a value whose robust z-score (median and MAD) against
+	// the last ZWindow steps exceeds it, once ZMinHistory steps exist. 0
+	// disables the check.
+	ZScore      float64 `yaml:"zscore"`
+	ZWindow     int     `yaml:"zscore_window"`      // default 30
+	ZMinHistory int     `yaml:"zscore_min_history"` // default 7
+}
+
+type CSVColumns struct {
+	Date string `yaml:"date"`
+	Oi   string `yaml:"oi"`
+	V    string `yaml:"v"`
+}
+
+// withDefaults fills unset column names with date, oi and v.
+func (c CSVColumns) withDefaults() CSVColumns {
+	if c.Date == "" {
+		c.Date = "date"
+	}
+	if c.Oi == "" {
+		c.Oi = "oi"
+	}
+	if c.V == "" {
+		c.V = "v"
+	}
+	return c
+}
+
+type HTTPSourceConfig struct {
+	// URL is fetched with GET; "{date}" is replaced by the step date
+	// (YYYY-MM-DD, schedule timezone).
+	URL string `yaml:"url"`
+	// OiPath and VPath select the values, e.g. "$.data.obligations" or
+	// "items[0].v". Numbers and numeric strings are accepted.
+	OiPath string `yaml:"oi_path"`
+	VPath  string `yaml:"v_path"`
+	// Headers are sent with every request; values may reference
+	// environment variables as ${NAME}.
+	Headers      map[string]string `yaml:"headers"`
+	Timeout      string            `yaml:"timeout"`       // default "10s"
+	Retries      int               `yaml:"retries"`       // extra attempts on transport errors, 429 and 5xx
+	RetryBackoff string            `yaml:"retry_backoff"` // default "2s"
+	TLS          HTTPSourceTLS     `yaml:"tls"`
+}
+
+type PrometheusSourceConfig struct {
+	// URL is the Prometheus server, e.g. "http://prometheus:9090".
+	URL string `yaml:"url"`
+	// OiQuery and VQuery are PromQL expressions evaluated at the step
+	// time, e.g. `increase(jobs_completed_total[1d])`. Each must return a
+	// scalar or a single-series vector with a non-negative value.
+	OiQuery      string            `yaml:"oi_query"`
+	VQuery       string            `yaml:"v_query"`
+	Headers      map[string]string `yaml:"headers"`
+	Timeout      string            `yaml:"timeout"`
+	Retries      int               `yaml:"retries"`
+	RetryBackoff string            `yaml:"retry_backoff"`
+	TLS          HTTPSourceTLS     `yaml:"tls"`
+}
+
+type HTTPSourceTLS struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// MaxAgeDuration returns the parsed max_age, or 0 when unset.
+func (t TelemetryConfig) MaxAgeDuration() time.Duration {
+	d, _ := time.ParseDuration(t.MaxAge)
+	return d
 }
 
 type ScheduleConfig struct {
 	RunTime  string `yaml:"run_time"`
 	Timezone string `yaml:"timezone"`
+	// CatchUp is the policy for scheduled steps missed while the server was
+	// down: "skip" (default) records a skipped entry for each, "combined"
+	// records skips and runs one step for the latest missed slot with the V
+	// of every missed slot added together, and
+	// "replay" runs each missed step with the telemetry for its date.
+	CatchUp string `yaml:"catch_up"`
 }
 
 type DashboardConfig struct {
@@ -102,7 +358,10 @@ func LoadConfig() (*ConfigFile, error) {
 		return nil, err
 	}
 
-	log.Printf("Loaded config: Pool=%s, Mode=%s, Port=%d", cfg.Pool.Name, cfg.Telemetry.Mode, cfg.Dashboard.Port)
+	for _, p := range cfg.Pools {
+		log.Printf("Loaded config: Pool=%s (%s), Mode=%s, Data=%s", p.Name, p.ID, p.Telemetry.Mode, p.DataDir)
+	}
+	log.Printf("Loaded config: %d pool(s), Port=%d", len(cfg.Pools), cfg.Dashboard.Port)
 	return &cfg, nil
 }
 
@@ -118,41 +377,418 @@ func copyExample() error {
 	return nil
 }
 
+// poolIDPattern restricts pool names in a pools: list to URL path segments.
+var poolIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateConfig checks the config and normalizes it: afterwards cfg.Pools
+// lists every pool with inherited sections filled in, IDs and data
+// directories assigned.
 func ValidateConfig(cfg *ConfigFile) error {
-	if cfg.Pool.MCap <= 0 {
-		return fmt.Errorf("pool.mcap must be > 0")
+	if len(cfg.Pools) == 0 {
+		cfg.Pools = []PoolSpec{{
+			PoolConfig: cfg.Pool,
+			DataDir:    "./data",
+			PDM:        cfg.PDM,
+			Resource:   cfg.Resource,
+			Telemetry:  cfg.Telemetry,
+			Schedule:   cfg.Schedule,
+			Operator:   cfg.Operator,
+			ID:         "default",
+		}}
+		if err := validatePool(&cfg.Pools[0], "pool.", ""); err != nil {
+			return err
+		}
+	} else {
+		ids := make(map[string]bool)
+		dirs := make(map[string]bool)
+		for i := range cfg.Pools {
+			p := &cfg.Pools[i]
+			pfx := fmt.Sprintf("pools[%d].", i)
+			if !poolIDPattern.MatchString(p.Name) {
+				return fmt.Errorf("%sname must be non-empty and contain only letters, digits, '-' and '_'", pfx)
+			}
+			if ids[p.Name] {
+				return fmt.Errorf("%sname %q is not unique", pfx, p.Name)
+			}
+			ids[p.Name] = true
+			p.ID = p.Name
+			if p.DataDir == "" {
+				p.DataDir = filepath.Join("./data", p.Name)
+			}
+			if dirs[filepath.Clean(p.DataDir)] {
+				return fmt.Errorf("%sdata_dir %q is shared with another pool", pfx, p.DataDir)
+			}
+			dirs[filepath.Clean(p.DataDir)] = true
+			if p.PDM == (PDMParams{}) {
+				p.PDM = cfg.PDM
+			}
+			if p.Resource == (ResourceConfig{}) {
+				p.Resource = cfg.Resource
+			}
+			if p.Telemetry == (TelemetryConfig{}) {
+				p.Telemetry = cfg.Telemetry
+			}
+			if p.Schedule == (ScheduleConfig{}) {
+				p.Schedule = cfg.Schedule
+			}
+			if p.Operator == (OperatorConfig{}) {
+				p.Operator = cfg.Operator
+			}
+			if err := validatePool(p, pfx, pfx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.Dashboard.Port < 1024 || cfg.Dashboard.Port > 65535 {
+		return fmt.Errorf("dashboard.port must be 1024-65535")
+	}
+
+	if cfg.Dashboard.ShowHistoryDays <= 0 {
+		cfg.Dashboard.ShowHistoryDays = 30 // Default
+	}
+
+	return nil
+}
+
+// validatePool checks one pool. poolPfx prefixes the pool fields (mcap,
+// initial_s) and sectionPfx the pdm, telemetry and schedule sections in
+// error messages, so they name the offending key as written in config.yaml.
+func validatePool(p *PoolSpec, poolPfx, sectionPfx string) error {
+	if p.MCap <= 0 {
+		return fmt.Errorf("%smcap must be > 0", poolPfx)
 	}
-	if cfg.Pool.InitialS < 0 || cfg.Pool.InitialS > cfg.Pool.MCap {
-		return fmt.Errorf("pool.initial_s must be [0, mcap]")
+	if p.InitialS < 0 || p.InitialS > p.MCap {
+		return fmt.Errorf("%sinitial_s must be [0, mcap]", poolPfx)
 	}
 
-	validModes := map[string]bool{"manual": true, "csv": true, "webhook": true}
-	if !validModes[cfg.Telemetry.Mode] {
-		return fmt.Errorf("telemetry.mode must be 'manual', 'csv', or 'webhook'")
+	if p.PDM.BurnBase != nil && *p.PDM.BurnBase < 0 {
+		return fmt.Errorf("%spdm.burn_base must be >= 0", sectionPfx)
+	}
+	if p.PDM.BurnVelocityK != nil && *p.PDM.BurnVelocityK < 0 {
+		return fmt.Errorf("%spdm.burn_velocity_k must be >= 0", sectionPfx)
+	}
+	if err := pdm.ValidatePDMConfig(p.PDMConfig(), p.MCap); err != nil {
+		return fmt.Errorf("%spdm: %v", sectionPfx, err)
 	}
 
-	if cfg.Telemetry.Mode == "csv" {
-		if strings.TrimSpace(cfg.Telemetry.CSVPath) == "" {
-			return fmt.Errorf("telemetry.csv_path is required when telemetry.mode is csv")
+	if err := validateTelemetry(&p.Telemetry, sectionPfx+"telemetry.", true); err != nil {
+		return err
+	}
+
+	switch p.Telemetry.OnFailure {
+	case "":
+		p.Telemetry.OnFailure = "skip"
+	case "skip", "hold", "abort":
+	default:
+		return fmt.Errorf("%stelemetry.on_failure must be 'skip', 'hold', or 'abort'", sectionPfx)
+	}
+	if p.Telemetry.Mode == "quorum" && p.Telemetry.OnFailure == "hold" {
+		// Holding would step on values no quorum agreed to.
+		return fmt.Errorf("%stelemetry.on_failure 'hold' cannot be used with quorum mode; use 'skip' or 'abort'", sectionPfx)
+	}
+
+	if sc := p.Telemetry.Sanity; sc != nil {
+		if err := validateSanity(sc); err != nil {
+			return fmt.Errorf("%stelemetry.sanity.%v", sectionPfx, err)
 		}
 	}
 
-	if _, err := time.Parse("15:04", cfg.Schedule.RunTime); err != nil {
-		return fmt.Errorf("schedule.run_time must be HH:MM format")
+	if err := validateOperator(&p.Operator, &p.Telemetry, sectionPfx); err != nil {
+		return err
+	}
+
+	if _, err := time.Parse("15:04", p.Schedule.RunTime); err != nil {
+		return fmt.Errorf("%sschedule.run_time must be HH:MM format", sectionPfx)
 	}
 
 	// Validate timezone
-	if _, err := time.LoadLocation(cfg.Schedule.Timezone); err != nil {
-		return fmt.Errorf("schedule.timezone is invalid: %v", err)
+	if _, err := time.LoadLocation(p.Schedule.Timezone); err != nil {
+		return fmt.Errorf("%sschedule.timezone is invalid: %v", sectionPfx, err)
 	}
 
-	if cfg.Dashboard.Port < 1024 || cfg.Dashboard.Port > 65535 {
-		return fmt.Errorf("dashboard.port must be 1024-65535")
+	if p.GenesisDate != "" {
+		at, err := genesisTime(p.Schedule, p.GenesisDate)
+		if err != nil {
+			return fmt.Errorf("%sgenesis_date must be YYYY-MM-DD", poolPfx)
+		}
+		if at.After(time.Now()) {
+			return fmt.Errorf("%sgenesis_date must not be in the future", poolPfx)
+		}
 	}
 
-	if cfg.Dashboard.ShowHistoryDays <= 0 {
-		cfg.Dashboard.ShowHistoryDays = 30 // Default
+	switch p.Schedule.CatchUp {
+	case "":
+		p.Schedule.CatchUp = pdm.SkipPolicySkip
+	case pdm.SkipPolicySkip, pdm.SkipPolicyCombined, pdm.SkipPolicyReplay:
+	default:
+		return fmt.Errorf("%sschedule.catch_up must be 'skip', 'combined', or 'replay'", sectionPfx)
+	}
+
+	return nil
+}
+
+// validateHTTPSource checks the telemetry.http section. Errors start with the
+// offending sub-key so the caller can prefix "telemetry.http".
+func validateHTTPSource(h *HTTPSourceConfig) error {
+	if h == nil {
+		return fmt.Errorf(" section is required when telemetry.mode is http")
+	}
+	u, err := url.Parse(strings.ReplaceAll(h.URL, "{date}", "2006-01-02"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf(".url must be an http or https URL")
+	}
+	if strings.TrimSpace(h.OiPath) == "" || strings.TrimSpace(h.VPath) == "" {
+		return fmt.Errorf(".oi_path and .v_path are required")
+	}
+	return validatePullClient(h.Timeout, h.RetryBackoff, h.Retries, h.TLS)
+}
+
+// validatePrometheusSource checks the telemetry.prometheus section, in the
+// manner of validateHTTPSource.
+func validatePrometheusSource(pr *PrometheusSourceConfig) error {
+	if pr == nil {
+		return fmt.Errorf(" section is required when telemetry.mode is prometheus")
+	}
+	u, err := url.Parse(pr.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf(".url must be an http or https URL")
+	}
+	if strings.TrimSpace(pr.OiQuery) == "" || strings.TrimSpace(pr.VQuery) == "" {
+		return fmt.Errorf(".oi_query and .v_query are required")
 	}
+	return validatePullClient(pr.Timeout, pr.RetryBackoff, pr.Retries, pr.TLS)
+}
 
+// validatePullClient checks the client settings shared by the pull sources.
+func validatePullClient(timeout, backoff string, retries int, tls HTTPSourceTLS) error {
+	for key, d := range map[string]string{"timeout": timeout, "retry_backoff": backoff} {
+		if d == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d); err != nil || v <= 0 {
+			return fmt.Errorf(".%s must be a positive duration such as \"10s\"", key)
+		}
+	}
+	if retries < 0 || retries > 10 {
+		return fmt.Errorf(".retries must be between 0 and 10")
+	}
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		return fmt.Errorf(".tls.cert_file and .tls.key_file must be set together")
+	}
+	return nil
+}
+
+// validateSanity checks the telemetry.sanity section and fills in defaults.
+// Errors start with the offending key.
+func validateSanity(sc *SanityConfig) error {
+	for _, b := range []struct {
+		name     string
+		min, max *float64
+	}{{"oi", sc.OiMin, sc.OiMax}, {"v", sc.VMin, sc.VMax}} {
+		if b.min != nil && *b.min < 0 {
+			return fmt.Errorf("%s_min must be >= 0", b.name)
+		}
+		if b.min != nil && b.max != nil && *b.min > *b.max {
+			return fmt.Errorf("%s_min must be <= %s_max", b.name, b.name)
+		}
+	}
+	if sc.MaxOiChange != 0 && sc.MaxOiChange <= 1 {
+		return fmt.Errorf("max_oi_change must be > 1 (or 0 to disable)")
+	}
+	if sc.MaxVChange != 0 && sc.MaxVChange <= 1 {
+		return fmt.Errorf("max_v_change must be > 1 (or 0 to disable)")
+	}
+	if sc.ZScore < 0 {
+		return fmt.Errorf("zscore must be >= 0")
+	}
+	if sc.ZWindow == 0 {
+		sc.ZWindow = 30
+	}
+	if sc.ZMinHistory == 0 {
+		sc.ZMinHistory = 7
+	}
+	if sc.ZMinHistory < 3 || sc.ZMinHistory > sc.ZWindow {
+		return fmt.Errorf("zscore_min_history must be between 3 and zscore_window")
+	}
+	return nil
+}
+
+// validateTelemetry checks the source settings of a telemetry section, the
+// pool's own or a quorum member's, and fills in defaults. pfx names the
+// section in errors. Only a pool's own section may be a quorum.
+func validateTelemetry(t *TelemetryConfig, pfx string, allowQuorum bool) error {
+	validModes := map[string]bool{"manual": true, "csv": true, "webhook": true, "http": true, "prometheus": true, "quorum": allowQuorum}
+	if !validModes[t.Mode] {
+		if allowQuorum {
+			return fmt.Errorf("%smode must be 'manual', 'csv', 'webhook', 'http', 'prometheus', or 'quorum'", pfx)
+		}
+		return fmt.Errorf("%smode must be 'manual', 'csv', 'webhook', 'http', or 'prometheus'", pfx)
+	}
+
+	switch t.Mode {
+	case "csv":
+		if strings.TrimSpace(t.CSVPath) == "" {
+			return fmt.Errorf("%scsv_path is required when mode is csv", pfx)
+		}
+	case "http":
+		if err := validateHTTPSource(t.HTTP); err != nil {
+			return fmt.Errorf("%shttp%v", pfx, err)
+		}
+	case "prometheus":
+		if err := validatePrometheusSource(t.Prometheus); err != nil {
+			return fmt.Errorf("%sprometheus%v", pfx, err)
+		}
+	case "quorum":
+		if err := validateQuorum(t.Quorum, pfx+"quorum"); err != nil {
+			return err
+		}
+	}
+
+	switch t.Aggregation {
+	case "":
+		t.Aggregation = "replace"
+	case "replace":
+	case "accumulate":
+		if t.Mode != "manual" && t.Mode != "webhook" {
+			return fmt.Errorf("%saggregation accumulate requires mode manual or webhook", pfx)
+		}
+	default:
+		return fmt.Errorf("%saggregation must be 'replace' or 'accumulate'", pfx)
+	}
+	switch t.OiGauge {
+	case "":
+		t.OiGauge = "last"
+	case "last", "max", "twa":
+	default:
+		return fmt.Errorf("%soi_gauge must be 'last', 'max', or 'twa'", pfx)
+	}
+
+	if t.MaxAge != "" {
+		if d, err := time.ParseDuration(t.MaxAge); err != nil || d <= 0 {
+			return fmt.Errorf("%smax_age must be a positive duration such as \"36h\"", pfx)
+		}
+	}
+
+	if t.Signing != nil {
+		if err := validateSigning(t.Signing); err != nil {
+			return fmt.Errorf("%ssigning%v", pfx, err)
+		}
+	}
+	return nil
+}
+
+// validateSigning checks a telemetry.signing section and fills in defaults.
+func validateSigning(s *SigningConfig) error {
+	if len(s.Keys) == 0 {
+		return fmt.Errorf(".keys must list at least one submitter")
+	}
+	for id, secret := range s.Keys {
+		if !poolIDPattern.MatchString(id) {
+			return fmt.Errorf(".keys: key id %q must contain only letters, digits, '-' and '_'", id)
+		}
+		if len(os.ExpandEnv(secret)) < 16 {
+			return fmt.Errorf(".keys.%s must be at least 16 characters (is its environment variable set?)", id)
+		}
+	}
+	if s.MaxSkew == "" {
+		s.MaxSkew = "5m"
+	}
+	if d, err := time.ParseDuration(s.MaxSkew); err != nil || d <= 0 {
+		return fmt.Errorf(".max_skew must be a positive duration such as \"5m\"")
+	}
+	return nil
+}
+
+// validateOperator checks an operator section and that it shares no token,
+// key id or secret with the pool's telemetry credentials.
+func validateOperator(op *OperatorConfig, t *TelemetryConfig, pfx string) error {
+	if op.Signing != nil {
+		if err := validateSigning(op.Signing); err != nil {
+			return fmt.Errorf("%soperator.signing%v", pfx, err)
+		}
+	}
+	tokens, keys := map[string]bool{}, map[string]bool{}
+	add := func(c TelemetryConfig) {
+		if c.AuthToken != "" {
+			tokens[c.AuthToken] = true
+		}
+		if c.Signing != nil {
+			for id, secret := range c.Signing.Keys {
+				keys[id] = true
+				tokens[os.ExpandEnv(secret)] = true
+			}
+		}
+	}
+	add(*t)
+	if t.Quorum != nil {
+		for _, src := range t.Quorum.Sources {
+			add(src.TelemetryConfig)
+		}
+	}
+	if op.AuthToken != "" && tokens[op.AuthToken] {
+		return fmt.Errorf("%soperator.auth_token must differ from every telemetry credential", pfx)
+	}
+	if op.Signing != nil {
+		for id, secret := range op.Signing.Keys {
+			if keys[id] || tokens[os.ExpandEnv(secret)] {
+				return fmt.Errorf("%soperator.signing.keys.%s must differ from every telemetry credential", pfx, id)
+			}
+		}
+	}
+	return nil
+}
+
+// validateQuorum checks a telemetry.quorum section and fills in defaults.
+func validateQuorum(q *QuorumConfig, pfx string) error {
+	if q == nil {
+		return fmt.Errorf("%s section is required when telemetry.mode is quorum", pfx)
+	}
+	n := len(q.Sources)
+	if n < 2 {
+		return fmt.Errorf("%s.sources must list at least two sources", pfx)
+	}
+	names := make(map[string]bool, n)
+	for i := range q.Sources {
+		src := &q.Sources[i]
+		spfx := fmt.Sprintf("%s.sources[%d].", pfx, i)
+		if !poolIDPattern.MatchString(src.Name) {
+			return fmt.Errorf("%sname must be non-empty and contain only letters, digits, '-' and '_'", spfx)
+		}
+		if names[src.Name] {
+			return fmt.Errorf("%sname %q is used twice", spfx, src.Name)
+		}
+		names[src.Name] = true
+		if err := validateTelemetry(&src.TelemetryConfig, spfx, false); err != nil {
+			return err
+		}
+	}
+
+	majority := n/2 + 1
+	switch q.Method {
+	case "", "median":
+		q.Method = "median"
+		if q.MinSources == 0 {
+			q.MinSources = majority
+		}
+		if q.MinSources < 1 || q.MinSources > n {
+			return fmt.Errorf("%s.min_sources must be between 1 and the number of sources", pfx)
+		}
+	case "agreement":
+		if q.K == 0 {
+			q.K = majority
+		}
+		if q.K < 2 || q.K > n {
+			return fmt.Errorf("%s.k must be between 2 and the number of sources", pfx)
+		}
+		if q.Tolerance == 0 {
+			q.Tolerance = 0.01
+		}
+		if q.Tolerance < 0 || q.Tolerance >= 1 {
+			return fmt.Errorf("%s.tolerance must be a fraction between 0 and 1", pfx)
+		}
+	default:
+		return fmt.Errorf("%s.method must be 'median' or 'agreement'", pfx)
+	}
 	return nil
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testConfigYAML = `
+pool: {name: "p", mcap: 1000000, initial_s: 618000}
+telemetry: {mode: manual}
+schedule: {run_time: "00:00", timezone: UTC}
+dashboard: {port: 8080}
+`
+
+func parseTestConfig(t *testing.T, extra string) (*ConfigFile, error) {
+	t.Helper()
+	var cfg ConfigFile
+	if err := yaml.Unmarshal([]byte(testConfigYAML+extra), &cfg); err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	return &cfg, ValidateConfig(&cfg)
+}
+
+func TestConfig_PDMSectionOverridesDefaults(t *testing.T) {
+	cfg, err := parseTestConfig(t, "pdm: {band_low: 0.59, burn_base: 0.001}\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := cfg.Pools[0].PDMConfig()
+	if got.BandLow != 0.59 || got.BurnBase != 0.001 {
+		t.Fatalf("overrides not applied: %+v", got)
+	}
+	if got.BandHigh != 0.62 || got.PhiTarget != 0.618 || got.MinS != 1e-9*1000000 {
+		t.Fatalf("omitted fields should keep defaults: %+v", got)
+	}
+}
+
+func TestConfig_PDMSectionValidated(t *testing.T) {
+	_, err := parseTestConfig(t, "pdm: {band_low: 0.63}\n")
+	if err == nil || !strings.Contains(err.Error(), "pdm:") {
+		t.Fatalf("expected pdm validation error, got %v", err)
+	}
+}
+
+func TestConfig_PoolsInheritTopLevelSections(t *testing.T) {
+	var cfg ConfigFile
+	err := yaml.Unmarshal([]byte(`
+telemetry: {mode: manual}
+schedule: {run_time: "00:00", timezone: UTC}
+dashboard: {port: 8080}
+pools:
+  - {name: compute, mcap: 1000, initial_s: 600}
+  - name: storage
+    mcap: 5000
+    initial_s: 3000
+    schedule: {run_time: "06:30", timezone: Europe/London}
+`), &cfg)
+	if err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Pools) != 2 || cfg.Pools[0].ID != "compute" || cfg.Pools[1].DataDir != "data/storage" {
+		t.Fatalf("unexpected pools: %+v", cfg.Pools)
+	}
+	if cfg.Pools[0].Schedule.RunTime != "00:00" || cfg.Pools[1].Schedule.RunTime != "06:30" {
+		t.Fatalf("schedule inheritance wrong: %+v / %+v", cfg.Pools[0].Schedule, cfg.Pools[1].Schedule)
+	}
+
+	cfg.Pools[1].Name = "compute"
+	cfg.Pools[1].DataDir = ""
+	if err := ValidateConfig(&cfg); err == nil || !strings.Contains(err.Error(), "not unique") {
+		t.Fatalf("expected duplicate name error, got %v", err)
+	}
+}
+
+func TestConfig_QuorumDefaultsAndRefusesHold(t *testing.T) {
+	const quorumYAML = `
+pool: {name: "p", mcap: 1000000, initial_s: 618000}
+schedule: {run_time: "00:00", timezone: UTC}
+dashboard: {port: 8080}
+telemetry:
+  mode: quorum
+  on_failure: %s
+  quorum:
+    method: agreement
+    sources:
+      - {name: ops, mode: webhook}
+      - {name: billing, mode: manual}
+      - {name: export, mode: csv, csv_path: export.csv}
+`
+	parse := func(onFailure string) (*ConfigFile, error) {
+		var cfg ConfigFile
+		if err := yaml.Unmarshal([]byte(strings.Replace(quorumYAML, "%s", onFailure, 1)), &cfg); err != nil {
+			t.Fatalf("yaml: %v", err)
+		}
+		return &cfg, ValidateConfig(&cfg)
+	}
+
+	cfg, err := parse("skip")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := cfg.Pools[0].Telemetry.Quorum; q.K != 2 || q.Tolerance != 0.01 {
+		t.Fatalf("expected k=2 and tolerance 0.01 by default, got %+v", q)
+	}
+	if _, err := parse("hold"); err == nil || !strings.Contains(err.Error(), "cannot be used with quorum") {
+		t.Fatalf("expected hold to be refused, got %v", err)
+	}
+}
+
+func TestConfig_OperatorCredentialDiffersFromTelemetry(t *testing.T) {
+	for _, tc := range []struct{ operator, want string }{
+		{`{auth_token: "s3cret"}`, "operator.auth_token must differ"},
+		{`{signing: {keys: {ops: "0123456789abcdef-ops"}}}`, "operator.signing.keys.ops must differ"},
+		{`{auth_token: "other", signing: {keys: {alice: "0123456789abcdef-alice"}}}`, ""},
+	} {
+		var cfg ConfigFile
+		err := yaml.Unmarshal([]byte(`
+pool: {name: "p", mcap: 1000000, initial_s: 618000}
+telemetry: {mode: manual, auth_token: "s3cret", signing: {keys: {ops: "0123456789abcdef-ops"}}}
+schedule: {run_time: "00:00", timezone: UTC}
+dashboard: {port: 8080}
+operator: `+tc.operator+"\n"), &cfg)
+		if err != nil {
+			t.Fatalf("yaml: %v", err)
+		}
+		err = ValidateConfig(&cfg)
+		if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Fatalf("operator %s: expected %q, got %v", tc.operator, tc.want, err)
+		}
+	}
+}
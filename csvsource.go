@@ -0,0 +1,263 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/csvsource.go
+// CSV telemetry source: header-mapped columns and an mtime-cached date index
+
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// csvDateLayouts are tried, after any configured layout, to read the date
+// column. Layouts without a zone are read in the schedule timezone; stamps
+// with a zone are converted to it before taking the date.
+var csvDateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+type CSVTelemetry struct {
+	csvPath string
+	loc     *time.Location
+	cols    CSVColumns
+	layout  string // extra layout from telemetry.csv_date_layout
+
+	mu    sync.Mutex
+	index *csvIndex
+}
+
+// csvIndex maps each date in the file to its row. It is rebuilt when the
+// file's size or modification time changes.
+type csvIndex struct {
+	modTime time.Time
+	size    int64
+	rows    map[string]csvRow
+}
+
+type csvRow struct {
+	line  int
+	oi, v float64
+	raw   string // the record as read, for its provenance hash
+	err   error  // a parse error or duplicate, reported when the date is fetched
+}
+
+// FetchDate returns Oi and V from the row for date (YYYY-MM-DD).
+func (c *CSVTelemetry) FetchDate(date string) (float64, float64, error) {
+	oi, v, _, err := c.Fetch(date)
+	return oi, v, err
+}
+
+// Fetch is FetchDate, also returning the row's provenance: the file and
+// line, and the hash of the record.
+func (c *CSVTelemetry) Fetch(date string) (float64, float64, *pdm.Provenance, error) {
+	idx, err := c.currentIndex()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	row, ok := idx.rows[date]
+	if !ok {
+		return 0, 0, nil, fmt.Errorf("no data for %s", date)
+	}
+	if row.err != nil {
+		return 0, 0, nil, row.err
+	}
+	modified := idx.modTime.UTC()
+	prov := &pdm.Provenance{
+		Mode:        "csv",
+		Source:      fmt.Sprintf("%s:%d", c.csvPath, row.line),
+		SubmittedAt: &modified,
+		PayloadHash: payloadHash([]byte(row.raw)),
+	}
+	return row.oi, row.v, prov, nil
+}
+
+// currentIndex returns the cached index, rebuilding it if the file changed.
+func (c *CSVTelemetry) currentIndex() (*csvIndex, error) {
+	fi, err := os.Stat(c.csvPath)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.index != nil && c.index.size == fi.Size() && c.index.modTime.Equal(fi.ModTime()) {
+		return c.index, nil
+	}
+	idx, err := c.buildIndex()
+	if err != nil {
+		return nil, err
+	}
+	idx.modTime, idx.size = fi.ModTime(), fi.Size()
+	c.index = idx
+	return idx, nil
+}
+
+// buildIndex streams the file once, keeping only the three mapped columns
+// of each row.
+func (c *CSVTelemetry) buildIndex() (*csvIndex, error) {
+	f, err := os.Open(c.csvPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rows, _, err := c.readRows(f)
+	if err != nil {
+		return nil, err
+	}
+	return &csvIndex{rows: rows}, nil
+}
+
+// readRows reads CSV telemetry from r into rows keyed by date. unread lists
+// the lines skipped for a missing column or an unreadable date.
+func (c *CSVTelemetry) readRows(r io.Reader) (rows map[string]csvRow, unread []int, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("CSV needs header + data")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	di, oi, vi, err := c.columns(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows = make(map[string]csvRow)
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rec) <= di || len(rec) <= oi || len(rec) <= vi {
+			unread = append(unread, line)
+			continue
+		}
+		date, ok := c.parseDate(rec[di])
+		if !ok {
+			unread = append(unread, line)
+			continue
+		}
+		if prev, dup := rows[date]; dup {
+			prev.err = fmt.Errorf("CSV has duplicate rows for %s (lines %d and %d)", date, prev.line, line)
+			rows[date] = prev
+			continue
+		}
+		row := parseCSVRow(line, rec[oi], rec[vi])
+		row.raw = strings.Join(rec, ",")
+		rows[date] = row
+	}
+	return rows, unread, nil
+}
+
+// columns locates the date, Oi and V columns by header name (case and
+// surrounding space ignored). A file whose header lacks the default names
+// is read positionally as date,oi,v, as earlier versions did.
+func (c *CSVTelemetry) columns(header []string) (int, int, int, error) {
+	want := c.cols.withDefaults()
+	pos := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, seen := pos[h]; !seen {
+			pos[h] = i
+		}
+	}
+	idx := [3]int{}
+	for i, name := range []string{want.Date, want.Oi, want.V} {
+		p, ok := pos[strings.ToLower(name)]
+		if !ok {
+			if c.cols == (CSVColumns{}) && len(header) >= 3 {
+				return 0, 1, 2, nil
+			}
+			return 0, 0, 0, fmt.Errorf("CSV header has no %q column", name)
+		}
+		idx[i] = p
+	}
+	return idx[0], idx[1], idx[2], nil
+}
+
+// parseDate reads a date or timestamp cell as a schedule-timezone date.
+func (c *CSVTelemetry) parseDate(raw string) (string, bool) {
+	ds := strings.TrimSpace(strings.TrimPrefix(raw, "\ufeff")) // handle UTF-8 BOM if present
+	loc := c.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	layouts := csvDateLayouts
+	if c.layout != "" {
+		layouts = append([]string{c.layout}, csvDateLayouts...)
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, ds, loc); err == nil {
+			return t.In(loc).Format("2006-01-02"), true
+		}
+	}
+	return "", false
+}
+
+func parseCSVRow(line int, rawOi, rawV string) csvRow {
+	row := csvRow{line: line}
+	var err error
+	if row.oi, err = strconv.ParseFloat(strings.TrimSpace(rawOi), 64); err != nil {
+		row.err = fmt.Errorf("CSV Oi parse error on line %d: %v", line, err)
+		return row
+	}
+	if row.v, err = strconv.ParseFloat(strings.TrimSpace(rawV), 64); err != nil {
+		row.err = fmt.Errorf("CSV V parse error on line %d: %v", line, err)
+		return row
+	}
+
+	// Enforce the same constraints as the POST endpoint for parity across telemetry modes.
+	if !isFinite(row.oi) || !isFinite(row.v) {
+		row.err = fmt.Errorf("CSV values on line %d must be finite numbers", line)
+	} else if row.oi <= 0 {
+		row.err = errors.New("CSV Oi must be > 0")
+	} else if row.v < 0 {
+		row.err = errors.New("CSV V must be >= 0")
+	}
+	return row
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeCSV(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCSVTelemetry_HeaderMappingAndLayouts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "t.csv")
+	writeCSV(t, path, "site,Observed At,activity,obligations\n"+
+		"x,2026-03-01T23:30:00-05:00,40000,1000000\n"+ // 2026-03-02 04:30 UTC
+		"x,2026-03-03 12:00:00,55000,1100000\n")
+	c := &CSVTelemetry{csvPath: path, loc: time.UTC, cols: CSVColumns{Date: "observed at", Oi: "obligations", V: "activity"}}
+
+	if oi, v, err := c.FetchDate("2026-03-02"); err != nil || oi != 1000000 || v != 40000 {
+		t.Fatalf("zoned timestamp: oi=%v v=%v err=%v", oi, v, err)
+	}
+	if _, v, err := c.FetchDate("2026-03-03"); err != nil || v != 55000 {
+		t.Fatalf("local timestamp: v=%v err=%v", v, err)
+	}
+
+	c.cols.V = "volume"
+	c.index = nil
+	if _, _, err := c.FetchDate("2026-03-02"); err == nil || !strings.Contains(err.Error(), `"volume"`) {
+		t.Fatalf("expected missing column error, got %v", err)
+	}
+}
+
+func TestCSVTelemetry_DuplicatesAndPositionalFallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "t.csv")
+	writeCSV(t, path, "day,o,vol\n2026-03-01,1,1\n2026-03-02,2,2\n2026/03/02,3,3\n")
+	c := &CSVTelemetry{csvPath: path, loc: time.UTC}
+
+	if oi, _, err := c.FetchDate("2026-03-01"); err != nil || oi != 1 {
+		t.Fatalf("positional fallback: oi=%v err=%v", oi, err)
+	}
+	if _, _, err := c.FetchDate("2026-03-02"); err == nil || !strings.Contains(err.Error(), "lines 3 and 4") {
+		t.Fatalf("expected duplicate-date error, got %v", err)
+	}
+}
+
+func TestCSVTelemetry_IndexFollowsFileChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "t.csv")
+	writeCSV(t, path, "date,oi,v\n2026-03-01,1000000,1\n")
+	c := &CSVTelemetry{csvPath: path, loc: time.UTC}
+	if _, _, err := c.FetchDate("2026-03-02"); err == nil {
+		t.Fatal("expected no data for 2026-03-02")
+	}
+	first := c.index
+
+	if _, _, err := c.FetchDate("2026-03-01"); err != nil || c.index != first {
+		t.Fatalf("unchanged file should reuse the index (err=%v)", err)
+	}
+
+	writeCSV(t, path, "date,oi,v\n2026-03-01,1000000,1\n2026-03-02,1000000,2\n")
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+	if _, v, err := c.FetchDate("2026-03-02"); err != nil || v != 2 {
+		t.Fatalf("appended row not picked up: v=%v err=%v", v, err)
+	}
+}
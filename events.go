@@ -0,0 +1,235 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/events.go
+// Server-Sent Events stream of steps, telemetry, parameter and health changes
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types published on a pool's stream.
+const (
+	eventStep      = "step"      // a StepTrace was committed
+	eventTelemetry = "telemetry" // a telemetry submission was accepted
+	eventConfig    = "config"    // a parameter change was scheduled, withdrawn or applied
+	eventHealth    = "health"    // the pool's health status changed
+	eventResync    = "resync"    // the client missed events and should refetch state
+)
+
+const (
+	// eventBacklog is how many recent events a reconnecting client can
+	// resume from with Last-Event-ID.
+	eventBacklog = 256
+	// eventBuffer is how far a subscriber may fall behind before it is
+	// disconnected; it resumes from the backlog when it reconnects.
+	eventBuffer = 64
+	// eventHeartbeat keeps idle streams from being closed by proxies.
+	eventHeartbeat = 25 * time.Second
+)
+
+// Event is one message on a pool's stream. ID is "<epoch>-<seq>": the epoch
+// changes when the server restarts, so an ID from an earlier run is never
+// mistaken for a resumable one.
+type Event struct {
+	ID   string
+	Type string
+	Data json.RawMessage
+}
+
+// eventHub fans a pool's events out to its subscribers and keeps the recent
+// backlog for Last-Event-ID resume. The zero value is ready to use.
+type eventHub struct {
+	mu      sync.Mutex
+	epoch   string
+	seq     uint64
+	backlog []Event // the last eventBacklog events, oldest first
+	subs    map[chan Event]struct{}
+	health  string // last published health status
+}
+
+// publish sends an event of type typ with v as its JSON data.
+func (h *eventHub) publish(typ string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.epoch == "" {
+		h.epoch = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	h.seq++
+	ev := Event{ID: h.epoch + "-" + strconv.FormatUint(h.seq, 10), Type: typ, Data: data}
+	if len(h.backlog) == eventBacklog {
+		h.backlog = append(h.backlog[:0], h.backlog[1:]...)
+	}
+	h.backlog = append(h.backlog, ev)
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+			// Too slow: drop it, and let it resume on reconnect.
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe registers a subscriber and returns the backlog events after
+// lastID. resumed is false if lastID was given but can no longer be resumed
+// from: it is from an earlier run or older than the backlog.
+func (h *eventHub) subscribe(lastID string) (ch chan Event, missed []Event, resumed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch = make(chan Event, eventBuffer)
+	if h.subs == nil {
+		h.subs = map[chan Event]struct{}{}
+	}
+	h.subs[ch] = struct{}{}
+	if lastID == "" {
+		return ch, nil, true
+	}
+
+	epoch, seqStr, _ := strings.Cut(lastID, "-")
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || epoch != h.epoch || seq > h.seq {
+		return ch, nil, false
+	}
+	first := h.seq - uint64(len(h.backlog)) + 1 // seq of backlog[0]
+	if seq+1 < first {
+		return ch, nil, false
+	}
+	return ch, append([]Event(nil), h.backlog[seq+1-first:]...), true
+}
+
+func (h *eventHub) unsubscribe(ch chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+// poolHealth is the data of a health event.
+type poolHealth struct {
+	Pool   string `json:"pool"`
+	Status string `json:"status"` // "ok", "quarantined", "halted" or "shutting_down"
+	Reason string `json:"reason,omitempty"`
+}
+
+// noteHealth publishes a health event if the pool's status has changed
+// since the last one.
+func (p *Pool) noteHealth() {
+	ph := poolHealth{Pool: p.Spec.ID, Status: "ok"}
+	p.mu.RLock()
+	switch {
+	case p.halted != "":
+		ph.Status, ph.Reason = "halted", p.halted
+	case p.quarantine != nil:
+		ph.Status = "quarantined"
+		ph.Reason = "step at " + p.quarantine.ScheduledAt.Format(time.RFC3339) + " awaits confirmation: " + strings.Join(p.quarantine.Reasons, "; ")
+	}
+	p.mu.RUnlock()
+	p.publishHealth(ph)
+}
+
+func (p *Pool) publishHealth(ph poolHealth) {
+	p.events.mu.Lock()
+	last := p.events.health
+	p.events.health = ph.Status
+	p.events.mu.Unlock()
+	// A fresh pool starts out "ok" without announcing it.
+	if ph.Status == last || (last == "" && ph.Status == "ok") {
+		return
+	}
+	p.events.publish(eventHealth, ph)
+}
+
+// eventsHandler streams the pool's events as text/event-stream. A client
+// reconnecting with Last-Event-ID (or ?last_event_id=) receives the events
+// it missed, or a resync event if they are no longer held.
+func (p *Pool) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "only GET allowed")
+		return
+	}
+	rc := http.NewResponseController(w)
+	// The server's write timeout is meant for ordinary responses.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		p.log.Printf("Event stream write deadline error: %v", err)
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	ch, missed, resumed := p.events.subscribe(lastID)
+	defer p.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if !resumed {
+		fmt.Fprintf(w, "event: %s\ndata: {\"last_event_id\":%q}\n\n", eventResync, lastID)
+	}
+	for _, ev := range missed {
+		writeEvent(w, ev)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			writeEvent(w, ev)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+}
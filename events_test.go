@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventHub_ResumesFromBacklog(t *testing.T) {
+	var h eventHub
+	for i := 0; i < 3; i++ {
+		h.publish(eventTelemetry, i)
+	}
+	first := h.backlog[0].ID
+
+	ch, missed, resumed := h.subscribe(first)
+	defer h.unsubscribe(ch)
+	if !resumed || len(missed) != 2 || string(missed[0].Data) != "1" {
+		t.Fatalf("expected the two events after %s, got %v (resumed %v)", first, missed, resumed)
+	}
+
+	for i := 3; i < eventBacklog+3; i++ {
+		h.publish(eventTelemetry, i)
+	}
+	if _, _, resumed := h.subscribe(first); resumed {
+		t.Fatal("expected an event older than the backlog not to resume")
+	}
+	if _, _, resumed := h.subscribe("0-1"); resumed {
+		t.Fatal("expected an id from an earlier run not to resume")
+	}
+	// The slow subscriber was dropped once its buffer filled.
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != eventBuffer {
+		t.Fatalf("expected %d buffered events before the drop, got %d", eventBuffer, n)
+	}
+}
+
+func TestEventsHandler_StreamsSteps(t *testing.T) {
+	p, _ := historyPool(t)
+	srv := httptest.NewServer(http.HandlerFunc(p.eventsHandler))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", p.events.backlog[4].ID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", ct)
+	}
+
+	lines := make(chan string)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+	next := func(prefix string) string {
+		t.Helper()
+		for {
+			select {
+			case l, ok := <-lines:
+				if !ok {
+					t.Fatalf("stream ended waiting for %q", prefix)
+				}
+				if strings.HasPrefix(l, prefix) {
+					return strings.TrimPrefix(l, prefix)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %q", prefix)
+			}
+		}
+	}
+
+	// The one step after the given id is replayed.
+	if id := next("id: "); id != p.events.backlog[5].ID {
+		t.Fatalf("expected the missed step %s, got %s", p.events.backlog[5].ID, id)
+	}
+	if typ := next("event: "); typ != eventStep {
+		t.Fatalf("expected a step event, got %s", typ)
+	}
+
+	p.mu.Lock()
+	p.halted = "test"
+	p.mu.Unlock()
+	p.noteHealth()
+	if typ := next("event: "); typ != eventHealth {
+		t.Fatalf("expected a health event, got %s", typ)
+	}
+	if data := next("data: "); !strings.Contains(data, `"status":"halted"`) {
+		t.Fatalf("expected the pool to report halted, got %s", data)
+	}
+}
@@ -0,0 +1,359 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/history.go
+// Paginated, filterable step history streamed from the journal
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// History page sizes: the default, and the most one request may ask for.
+const (
+	historyDefaultLimit = 100
+	historyMaxLimit     = 1000
+)
+
+// historyCSVHeader and historyCSVRecord are the columns of history.csv.
+var historyCSVHeader = []string{
+	"timestamp", "oi", "v_total", "s_prev", "s_new", "l_ratio", "clamped_s", "clamped_cap", "error",
+}
+
+func historyCSVRecord(trace pdm.StepTrace) []string {
+	return []string{
+		trace.Timestamp.Format("2006-01-02 15:04:05"),
+		strconv.FormatFloat(trace.Oi, 'f', 6, 64),
+		strconv.FormatFloat(trace.VTotal, 'f', 6, 64),
+		strconv.FormatFloat(trace.SPrev, 'f', 6, 64),
+		strconv.FormatFloat(trace.SNew, 'f', 6, 64),
+		strconv.FormatFloat(trace.L, 'f', 4, 64),
+		fmt.Sprintf("%t", trace.ClampedS),
+		fmt.Sprintf("%t", trace.ClampedCap),
+		trace.Error,
+	}
+}
+
+// historyQuery selects a page of steps. From is inclusive and Before
+// exclusive; the filters are unset when nil.
+type historyQuery struct {
+	From, Before time.Time
+	Minted       *bool // the step minted (Delta > 0)
+	Clamped      *bool // either clamp engaged
+	LBelow       *float64
+	Limit        int
+	Cursor       string // hash_chain_root of the last step already received
+}
+
+// match reports whether trace passes the query's filters.
+func (q historyQuery) match(trace pdm.StepTrace) bool {
+	if !q.From.IsZero() && trace.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.Before.IsZero() && !trace.Timestamp.Before(q.Before) {
+		return false
+	}
+	if q.Minted != nil && *q.Minted != (trace.Error == "" && trace.Delta > 0) {
+		return false
+	}
+	if q.Clamped != nil && *q.Clamped != (trace.ClampedS || trace.ClampedCap) {
+		return false
+	}
+	if q.LBelow != nil && !(trace.L < *q.LBelow) {
+		return false
+	}
+	return true
+}
+
+// parseHistoryQuery reads the query string. Dates are calendar days in loc;
+// `to` includes its whole day. RFC 3339 timestamps are taken exactly.
+func parseHistoryQuery(v map[string][]string, loc *time.Location) (historyQuery, error) {
+	get := func(k string) string {
+		if vs := v[k]; len(vs) > 0 {
+			return strings.TrimSpace(vs[0])
+		}
+		return ""
+	}
+	q := historyQuery{Limit: historyDefaultLimit, Cursor: get("cursor")}
+
+	bound := func(k string, end bool) (time.Time, error) {
+		s := get(k)
+		if s == "" {
+			return time.Time{}, nil
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			if end {
+				t = t.Add(time.Nanosecond)
+			}
+			return t, nil
+		}
+		day, err := time.ParseInLocation("2006-01-02", s, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: want YYYY-MM-DD or an RFC 3339 timestamp", k)
+		}
+		if end {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day, nil
+	}
+	var err error
+	if q.From, err = bound("from", false); err != nil {
+		return q, err
+	}
+	if q.Before, err = bound("to", true); err != nil {
+		return q, err
+	}
+	if !q.From.IsZero() && !q.Before.IsZero() && !q.From.Before(q.Before) {
+		return q, fmt.Errorf("from is after to")
+	}
+
+	for k, dst := range map[string]**bool{"minted": &q.Minted, "clamped": &q.Clamped} {
+		if s := get(k); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return q, fmt.Errorf("%s: want true or false", k)
+			}
+			*dst = &b
+		}
+	}
+	if s := get("l_below"); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return q, fmt.Errorf("l_below: want a number")
+		}
+		q.LBelow = &f
+	}
+	if s := get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > historyMaxLimit {
+			return q, fmt.Errorf("limit: want 1 to %d", historyMaxLimit)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// historyFormat picks the response format from ?format= or the Accept
+// header: "json" (default), "ndjson" or "csv".
+func historyFormat(r *http.Request) (string, error) {
+	switch f := r.URL.Query().Get("format"); f {
+	case "json", "ndjson", "csv":
+		return f, nil
+	case "":
+	default:
+		return "", fmt.Errorf("format: want json, ndjson or csv")
+	}
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/x-ndjson"), strings.Contains(accept, "application/ndjson"):
+		return "ndjson", nil
+	case strings.Contains(accept, "text/csv"):
+		return "csv", nil
+	}
+	return "json", nil
+}
+
+// historyHandler serves a page of the pool's steps, oldest first. It reads
+// the journal file rather than the in-memory history, and holds p.mu only to
+// resolve the cursor and fix the page's upper bound at the entries committed
+// when the request arrived.
+func (p *Pool) historyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "only GET allowed")
+		return
+	}
+	format, err := historyFormat(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q, err := parseHistoryQuery(r.URL.Query(), scheduleLocation(p.Spec.Schedule))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	p.mu.RLock()
+	end := len(p.state.Journal)
+	start := -1
+	if q.Cursor == "" {
+		start = 0
+	} else {
+		for i, e := range p.state.Journal {
+			if e.Step != nil && e.Step.HashChainRoot == q.Cursor {
+				start = i + 1
+				break
+			}
+		}
+	}
+	p.mu.RUnlock()
+	if start < 0 {
+		writeJSONError(w, http.StatusBadRequest, "cursor: no step with that hash_chain_root")
+		return
+	}
+
+	f, err := os.Open(p.dataDir + "/" + journalFile)
+	if err != nil && !os.IsNotExist(err) {
+		p.log.Printf("History journal open error: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "journal unavailable")
+		return
+	}
+	if f != nil {
+		defer f.Close()
+	}
+
+	out := newHistoryWriter(w, format, p.Spec.ID)
+	var last *pdm.StepTrace
+	more := false
+	if f != nil && start < end {
+		i := -1
+		err = scanJournal(f, func(e pdm.Entry) bool {
+			i++
+			if i >= end {
+				return false
+			}
+			if i < start || e.Step == nil || !q.match(*e.Step) {
+				return true
+			}
+			if out.n == q.Limit {
+				more = true
+				return false
+			}
+			out.write(*e.Step)
+			last = e.Step
+			return true
+		})
+		if err != nil {
+			// The 200 has gone out, so a clean close would pass the
+			// truncated page off as the last one. Abort the response
+			// instead so the client sees a failed transfer.
+			p.log.Printf("History journal read error: %v", err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+	next := ""
+	if more {
+		next = last.HashChainRoot
+	}
+	if err := out.close(next); err != nil {
+		p.log.Printf("History write error: %v", err)
+	}
+}
+
+// historyNextCursor is the trailer carrying the cursor of the next page,
+// for formats that have nowhere else to put it.
+const historyNextCursor = "X-PDM-Next-Cursor"
+
+// historyWriter streams steps in one of the history formats. JSON is a
+// {"pool_id","steps","next_cursor"} object; NDJSON is one trace per line;
+// CSV has the history.csv columns plus delta and hash_chain_root.
+type historyWriter struct {
+	w      http.ResponseWriter
+	format string
+	n      int // steps written
+	enc    *json.Encoder
+	csv    *csv.Writer
+	err    error
+}
+
+func newHistoryWriter(w http.ResponseWriter, format, poolID string) *historyWriter {
+	h := &historyWriter{w: w, format: format}
+	switch format {
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		h.enc = json.NewEncoder(w)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		h.csv = csv.NewWriter(w)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("Trailer", historyNextCursor)
+	w.WriteHeader(http.StatusOK)
+
+	switch format {
+	case "csv":
+		h.err = h.csv.Write(append(historyCSVHeader, "delta", "hash_chain_root"))
+	case "json":
+		id, _ := json.Marshal(poolID)
+		_, h.err = fmt.Fprintf(w, `{"pool_id":%s,"steps":[`, id)
+	}
+	return h
+}
+
+func (h *historyWriter) write(trace pdm.StepTrace) {
+	if h.err != nil {
+		return
+	}
+	switch h.format {
+	case "ndjson":
+		h.err = h.enc.Encode(trace)
+	case "csv":
+		h.err = h.csv.Write(append(historyCSVRecord(trace),
+			strconv.FormatFloat(trace.Delta, 'f', 6, 64), trace.HashChainRoot))
+	default:
+		line, err := json.Marshal(trace)
+		if err != nil {
+			h.err = err
+			return
+		}
+		if h.n > 0 {
+			line = append([]byte{','}, line...)
+		}
+		_, h.err = h.w.Write(line)
+	}
+	h.n++
+}
+
+// close ends the response. next is the cursor of the following page, or ""
+// when this page is the last.
+func (h *historyWriter) close(next string) error {
+	switch h.format {
+	case "csv":
+		h.csv.Flush()
+		if h.err == nil {
+			h.err = h.csv.Error()
+		}
+	case "json":
+		if h.err == nil {
+			tail := "]}"
+			if next != "" {
+				tail = fmt.Sprintf(`],"next_cursor":%q}`, next)
+			}
+			_, h.err = h.w.Write([]byte(tail))
+		}
+	}
+	if next != "" {
+		h.w.Header().Set(historyNextCursor, next)
+	}
+	return h.err
+}
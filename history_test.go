@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pdm-personal/pdm"
+)
+
+// historyPool journals six daily steps from 2026-01-01; the odd days have a
+// high Oi, so L falls below the band and they mint.
+func historyPool(t *testing.T) (*Pool, []pdm.StepTrace) {
+	t.Helper()
+	p := testPool(t)
+	p.state = PoolState{S: p.Spec.InitialS, MCap: p.Spec.MCap, Config: p.Spec.PDMConfig()}
+	traces := testTraces(6, "", func(i int) float64 {
+		if i%2 == 1 {
+			return 5000000
+		}
+		return 1000000
+	})
+	for _, tr := range traces {
+		if err := p.persist(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p, traces
+}
+
+type historyPage struct {
+	Steps      []pdm.StepTrace `json:"steps"`
+	NextCursor string          `json:"next_cursor"`
+}
+
+func getHistory(t *testing.T, p *Pool, query string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/pdm/v1/history?"+query, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	p.historyHandler(rec, req)
+	return rec
+}
+
+func TestHistory_PagesWithCursor(t *testing.T) {
+	p, traces := historyPool(t)
+
+	var got []pdm.StepTrace
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("cursor did not terminate")
+		}
+		rec := getHistory(t, p, "limit=4&cursor="+cursor, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+		var page historyPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decode failed: %v\n%s", err, rec.Body)
+		}
+		got = append(got, page.Steps...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if len(got) != len(traces) || got[5].HashChainRoot != traces[5].HashChainRoot {
+		t.Fatalf("expected all %d steps in order, got %d", len(traces), len(got))
+	}
+
+	if rec := getHistory(t, p, "cursor=nope", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown cursor, got %d", rec.Code)
+	}
+}
+
+func TestHistory_FiltersAndFormats(t *testing.T) {
+	p, traces := historyPool(t)
+
+	rec := getHistory(t, p, "minted=true&from=2026-01-02&to=2026-01-04", nil)
+	var page historyPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode failed: %v\n%s", err, rec.Body)
+	}
+	if len(page.Steps) != 2 || page.Steps[0].HashChainRoot != traces[1].HashChainRoot ||
+		page.Steps[1].HashChainRoot != traces[3].HashChainRoot {
+		t.Fatalf("expected the minting steps of Jan 2 and 4, got %+v", page.Steps)
+	}
+
+	rec = getHistory(t, p, "l_below=0.5&limit=1", http.Header{"Accept": {"application/x-ndjson"}})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("expected NDJSON, got %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	var tr pdm.StepTrace
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &tr) != nil || tr.L >= 0.5 {
+		t.Fatalf("expected one step with L < 0.5, got %q", rec.Body)
+	}
+	if rec.Result().Trailer.Get(historyNextCursor) == "" {
+		t.Fatalf("expected a next-cursor trailer on a full page")
+	}
+
+	rec = getHistory(t, p, "format=csv&minted=false", nil)
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][len(rows[0])-1] != "hash_chain_root" || rows[1][len(rows[1])-1] != traces[0].HashChainRoot {
+		t.Fatalf("expected header and three non-minting rows, got %v", rows)
+	}
+
+	if rec := getHistory(t, p, "from=2026-01-05&to=2026-01-01", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an empty range, got %d", rec.Code)
+	}
+}
+
+func TestHistory_AbortsOnJournalError(t *testing.T) {
+	p, _ := historyPool(t)
+	path := filepath.Join(p.dataDir, journalFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	os.WriteFile(path, []byte(lines[0]+"{not json\n"+strings.Join(lines[1:], "")), 0644)
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Fatalf("expected the response to be aborted, got %v", r)
+		}
+	}()
+	getHistory(t, p, "", nil)
+	t.Fatal("expected the response to be aborted")
+}
@@ -0,0 +1,251 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/httpsource.go
+// Pull telemetry source: polls an HTTP JSON endpoint at step time
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// HTTPTelemetry implements TelemetrySource by fetching a JSON document and
+// extracting Oi and V with path expressions.
+type HTTPTelemetry struct {
+	cfg HTTPSourceConfig
+	get *httpGetter
+}
+
+// newHTTPTelemetry builds the source and its TLS client from a validated
+// telemetry.http section.
+func newHTTPTelemetry(cfg HTTPSourceConfig) (*HTTPTelemetry, error) {
+	get, err := newHTTPGetter("telemetry.http", cfg.Headers, cfg.Timeout, cfg.Retries, cfg.RetryBackoff, cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPTelemetry{cfg: cfg, get: get}, nil
+}
+
+// FetchDate requests the document for date and extracts Oi and V.
+func (h *HTTPTelemetry) FetchDate(date string) (float64, float64, error) {
+	oi, v, _, err := h.Fetch(date)
+	return oi, v, err
+}
+
+// Fetch is FetchDate, also returning the document's provenance: the URL, the
+// fetch time and the hash of the response body.
+func (h *HTTPTelemetry) Fetch(date string) (float64, float64, *pdm.Provenance, error) {
+	u := strings.ReplaceAll(h.cfg.URL, "{date}", url.QueryEscape(date))
+	body, err := h.get.Get(u)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("telemetry source: %v", err)
+	}
+	fetched := time.Now().UTC()
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return 0, 0, nil, fmt.Errorf("telemetry source: invalid JSON: %v", err)
+	}
+	oi, err := jsonPathNumber(doc, h.cfg.OiPath)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("telemetry source: oi_path: %v", err)
+	}
+	v, err := jsonPathNumber(doc, h.cfg.VPath)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("telemetry source: v_path: %v", err)
+	}
+	return oi, v, &pdm.Provenance{Mode: "http", Source: redactURL(u), SubmittedAt: &fetched, PayloadHash: payloadHash(body)}, nil
+}
+
+// redactURL reduces u to scheme, host and path before it is recorded on the
+// chain and in audit exports: user:password and query strings (where API
+// keys usually go) are dropped. A URL that does not parse is not recorded.
+func redactURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "(unparseable URL)"
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.ForceQuery = false
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
+
+// httpGetter issues GETs for the pull sources with their headers, timeout,
+// TLS settings and retry policy.
+type httpGetter struct {
+	client  *http.Client
+	headers map[string]string
+	retries int
+	backoff time.Duration
+}
+
+// newHTTPGetter builds a getter from validated settings. section prefixes
+// errors about the TLS files.
+func newHTTPGetter(section string, headers map[string]string, timeout string, retries int, backoff string, tlsOpts HTTPSourceTLS) (*httpGetter, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: tlsOpts.InsecureSkipVerify}
+	if tlsOpts.CAFile != "" {
+		pem, err := os.ReadFile(tlsOpts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s.tls.ca_file: %v", section, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s.tls.ca_file: no certificates found", section)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if tlsOpts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(tlsOpts.CertFile, tlsOpts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s.tls: %v", section, err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	g := &httpGetter{headers: headers, retries: retries, backoff: 2 * time.Second}
+	d := 10 * time.Second
+	if timeout != "" {
+		d, _ = time.ParseDuration(timeout)
+	}
+	if backoff != "" {
+		g.backoff, _ = time.ParseDuration(backoff)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+	g.client = &http.Client{Timeout: d, Transport: transport}
+	return g, nil
+}
+
+// Get returns the body of a 200 response from u, retrying transport errors,
+// 429 and 5xx responses up to g.retries times.
+func (g *httpGetter) Get(u string) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= g.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(g.backoff)
+		}
+		var body []byte
+		var retry bool
+		body, retry, err = g.get(u)
+		if err == nil {
+			return body, nil
+		}
+		if !retry {
+			break
+		}
+	}
+	return nil, err
+}
+
+func (g *httpGetter) get(u string) (body []byte, retry bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, val := range g.headers {
+		req.Header.Set(k, os.ExpandEnv(val))
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return nil, retry, fmt.Errorf("HTTP %d: %s", resp.StatusCode, msg)
+	}
+	return body, false, nil
+}
+
+// jsonPathNumber evaluates a dotted path such as "$.data.items[0].oi" against
+// a decoded JSON document. The value must be a number or a numeric string.
+func jsonPathNumber(doc interface{}, path string) (float64, error) {
+	cur := doc
+	for _, seg := range splitJSONPath(path) {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			next, ok := node[seg]
+			if !ok {
+				return 0, fmt.Errorf("%s: no field %q", path, seg)
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return 0, fmt.Errorf("%s: no index %s", path, seg)
+			}
+			cur = node[i]
+		default:
+			return 0, fmt.Errorf("%s: cannot descend into %q", path, seg)
+		}
+	}
+	switch n := cur.(type) {
+	case float64:
+		return n, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil || !isFinite(f) {
+			return 0, fmt.Errorf("%s: %q is not a finite number", path, n)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("%s: value is not a number", path)
+}
+
+// splitJSONPath turns "$.a.b[2].c" into ["a", "b", "2", "c"].
+func splitJSONPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	var segs []string
+	for _, s := range strings.Split(path, ".") {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	return segs
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPTelemetry_FetchDate(t *testing.T) {
+	var calls int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			http.Error(w, "warming up", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, "no", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("day") != "2026-03-02" {
+			http.Error(w, "wrong day", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"obligations":"1000000","series":[{"activity":50000}]}}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("PDM_TEST_TOKEN", "s3cret")
+	src, err := newHTTPTelemetry(HTTPSourceConfig{
+		URL:          srv.URL + "/figures?day={date}",
+		OiPath:       "$.data.obligations",
+		VPath:        "data.series[0].activity",
+		Headers:      map[string]string{"Authorization": "Bearer ${PDM_TEST_TOKEN}"},
+		Retries:      1,
+		RetryBackoff: "1ms",
+		TLS:          HTTPSourceTLS{InsecureSkipVerify: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oi, v, err := src.FetchDate("2026-03-02")
+	if err != nil || oi != 1000000 || v != 50000 {
+		t.Fatalf("got oi=%v v=%v err=%v", oi, v, err)
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("expected one retry after 503, got %d calls", calls)
+	}
+
+	// 4xx is not retried.
+	atomic.StoreInt32(&calls, 1)
+	if _, _, err := src.FetchDate("2026-03-03"); err == nil || !strings.Contains(err.Error(), "404") || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("expected a single 404 attempt, got calls=%d err=%v", calls, err)
+	}
+}
+
+func TestHTTPTelemetry_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	src, _ := newHTTPTelemetry(HTTPSourceConfig{URL: srv.URL, OiPath: "oi", VPath: "v", Timeout: "20ms"})
+	if _, _, err := src.FetchDate("2026-03-02"); err == nil {
+		t.Fatal("expected a timeout")
+	}
+}
+
+func TestJSONPathNumber(t *testing.T) {
+	doc := map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": 2.5}}, "s": "x", "nan": "NaN", "inf": "+Inf"}
+	if n, err := jsonPathNumber(doc, "$.a[0].b"); err != nil || n != 2.5 {
+		t.Fatalf("got %v %v", n, err)
+	}
+	for _, path := range []string{"a[1].b", "missing", "s", "a.b", "nan", "inf"} {
+		if _, err := jsonPathNumber(doc, path); err == nil {
+			t.Errorf("%s: expected error", path)
+		}
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	got := redactURL("https://user:pw@telemetry.example/v1/day?date=2026-03-01&api_key=s3cret#frag")
+	if got != "https://telemetry.example/v1/day" {
+		t.Fatalf("expected credentials and query dropped, got %q", got)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/journal.go
+// Append-only JSON Lines step journal: the system of record for traces
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"pdm-personal/pdm"
+)
+
+// journalFile holds one chain entry per line, in chain order: a complete
+// pdm.StepTrace for each step, or a pdm.ParamChange record. Lines are only
+// ever appended; state.json and history.csv are derived.
+const journalFile = "journal.jsonl"
+
+// genesisFile holds the sealed pdm.Genesis record the journal chains from.
+// It is written once when a pool is bootstrapped, dated pool.genesis_date if
+// set, and never rewritten.
+const genesisFile = "genesis.json"
+
+// writeGenesis persists g, refusing to overwrite an existing genesis.
+func writeGenesis(path string, g pdm.Genesis) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// readGenesis loads and checks the seal of a genesis record.
+func readGenesis(path string) (*pdm.Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var g pdm.Genesis
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if expected := pdm.HashGenesis(g); expected != g.HashChainRoot {
+		return nil, fmt.Errorf("%s: seal mismatch (recorded %s, recomputed %s)", path, g.HashChainRoot, expected)
+	}
+	return &g, nil
+}
+
+// appendJournal writes entry as a single line and syncs it to disk before
+// returning, so an acknowledged step survives a crash.
+func appendJournal(path string, entry pdm.Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// readJournal loads every entry in the journal. A final line without a
+// trailing newline is a write torn by a crash and is skipped with a warning;
+// a malformed line anywhere else is an error.
+func readJournal(path string) ([]pdm.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []pdm.Entry
+	err = scanJournal(f, func(e pdm.Entry) bool {
+		entries = append(entries, e)
+		return true
+	})
+	return entries, err
+}
+
+// scanJournal streams entries from r to fn until fn returns false.
+func scanJournal(r io.Reader, fn func(pdm.Entry) bool) error {
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		torn := err == io.EOF && len(line) > 0
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var e pdm.Entry
+			if jerr := json.Unmarshal(trimmed, &e); jerr != nil {
+				if torn {
+					log.Printf("WARNING: ignoring torn final journal line %d: %v", lineNo, jerr)
+					return nil
+				}
+				return fmt.Errorf("journal line %d: %v", lineNo, jerr)
+			}
+			if !fn(e) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// repairJournalTail makes sure the journal ends with a newline before new
+// lines are appended. A complete final record that only lost its newline is
+// kept; a partial record from a torn write is truncated away.
+func repairJournalTail(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 || data[len(data)-1] == '\n' {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	cut := bytes.LastIndexByte(data, '\n') + 1
+	var e pdm.Entry
+	if json.Unmarshal(bytes.TrimSpace(data[cut:]), &e) == nil {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write([]byte{'\n'})
+		return err
+	}
+	log.Printf("WARNING: truncating torn final journal record (%d bytes)", len(data)-cut)
+	return os.Truncate(path, int64(cut))
+}
+
+// configFromTrace reconstructs the control-law parameters recorded in a
+// trace. MinS and MinO are not traced and fall back to the defaults.
+func configFromTrace(trace pdm.StepTrace) pdm.PDMConfig {
+	cfg := pdm.DefaultConfig(trace.MCap)
+	cfg.PhiTarget = trace.PhiTarget
+	cfg.BandLow = trace.BandLow
+	cfg.BandHigh = trace.BandHigh
+	cfg.BurnBase = trace.BurnBase
+	cfg.BurnVelocityK = trace.BurnVelocityK
+	return cfg
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// testTraces returns n daily steps from 2026-01-01 of a pool with the
+// default parameters, MCap 1,000,000 and S 618,000, chained from anchor.
+// oi gives step i's Oi; nil means 1,000,000 throughout. V is 50,000.
+func testTraces(n int, anchor string, oi func(i int) float64) []pdm.StepTrace {
+	mcap := 1000000.0
+	cfg := pdm.DefaultConfig(mcap)
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var out []pdm.StepTrace
+	s, prev := 618000.0, anchor
+	for i := 0; i < n; i++ {
+		o := 1000000.0
+		if oi != nil {
+			o = oi(i)
+		}
+		var tr pdm.StepTrace
+		s, tr = pdm.StepPDMAt(at.AddDate(0, 0, i), s, o, 50000, mcap, prev, cfg)
+		prev = tr.HashChainRoot
+		out = append(out, tr)
+	}
+	return out
+}
+
+func TestJournal_TornTailIsRepaired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), journalFile)
+	traces := testTraces(3, "", nil)
+	for _, tr := range traces {
+		if err := appendJournal(path, pdm.Entry{Step: &tr}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"timestamp":"2026-01-04T00:00:00Z","s_pr`)
+	f.Close()
+
+	if err := repairJournalTail(path); err != nil {
+		t.Fatalf("repair failed: %v", err)
+	}
+	if err := appendJournal(path, pdm.StepEntries(testTraces(4, "", nil))[3]); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readJournal(path)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(got) != 4 || got[2].Root() != traces[2].HashChainRoot {
+		t.Fatalf("expected 4 intact traces after repair, got %d", len(got))
+	}
+	if rep := pdm.VerifyEntries(got, ""); !rep.Valid {
+		t.Fatalf("journal round trip broke the chain: %+v", rep)
+	}
+}
+
+func TestLoadState_RebuildsStaleSnapshotFromJournal(t *testing.T) {
+	p := testPool(t)
+
+	traces := testTraces(5, "", nil)
+	for _, tr := range traces {
+		appendJournal(filepath.Join(p.dataDir, journalFile), pdm.Entry{Step: &tr})
+	}
+	// Snapshot taken after step 3 only.
+	p.state = PoolState{S: traces[2].SNew, MCap: 1000000, Config: pdm.DefaultConfig(1000000), JournalEntries: 3, HeadRoot: traces[2].HashChainRoot}
+	p.saveSnapshot()
+
+	p.state = PoolState{}
+	p.loadState()
+	if p.state.S != traces[4].SNew || p.state.JournalEntries != 5 || len(p.state.History) != 5 {
+		t.Fatalf("expected rebuild to step 5 (S=%f), got S=%f steps=%d", traces[4].SNew, p.state.S, p.state.JournalEntries)
+	}
+}
+
+func TestLoadState_MigratesTrimmedLegacyHistory(t *testing.T) {
+	p := testPool(t)
+	// A pre-journal state.json trimmed to its last 365 traces, with no
+	// base root recorded.
+	kept := testTraces(legacyHistoryLimit+5, "", nil)[5:]
+	legacy := PoolState{S: kept[len(kept)-1].SNew, MCap: 1000000, Config: pdm.DefaultConfig(1000000), History: kept}
+	data, _ := json.Marshal(legacy)
+	if err := os.WriteFile(filepath.Join(p.dataDir, "state.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p.loadState()
+	if p.state.HistoryBase == nil || p.state.HistoryBaseRoot != kept[0].HashChainRoot || len(p.state.History) != legacyHistoryLimit-1 {
+		t.Fatalf("expected the oldest trace kept aside as the anchor, got base %q and %d steps", p.state.HistoryBaseRoot, len(p.state.History))
+	}
+	if rep := pdm.VerifyEntries(p.state.Journal, p.chainAnchor()); !rep.Valid {
+		t.Fatalf("migrated chain failed verification: %+v", rep.FirstBreak)
+	}
+
+	// The anchor survives a restart, when state.json no longer has history.
+	restarted := newPool(p.Spec)
+	restarted.loadState()
+	if rep := pdm.VerifyEntries(restarted.state.Journal, restarted.chainAnchor()); !rep.Valid || restarted.state.HistoryBase == nil {
+		t.Fatalf("anchor lost across restart: %+v", rep.FirstBreak)
+	}
+}
@@ -22,214 +22,130 @@ Provided "AS IS" without warranty of any kind.
 
 // pdm-personal/main.go
 // MannCert PDM Personal Edition v1.0.1
-// Integrates existing PDM core with personal config, telemetry, and dashboard
-// Core PDM logic (StepPDM, etc.) is unchanged and patent-locked
+// Integrates the PDM core (package pdm) with personal config, telemetry, and dashboard
+// Core PDM logic (StepPDM, etc.) lives in ./pdm and is patent-locked
 
 package main
 
 import (
-	"crypto/sha256"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"log"
-	"math"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
-)
-
-// ── Existing PDM Core (Unchanged) ──────────────────────────────────────
-
-type PDMConfig struct {
-	PhiTarget     float64 `json:"phi_target"`
-	BandLow       float64 `json:"band_low"`
-	BandHigh      float64 `json:"band_high"`
-	BurnBase      float64 `json:"burn_base"`
-	BurnVelocityK float64 `json:"burn_velocity_k"`
-	MinS          float64 `json:"min_s"`
-	MinO          float64 `json:"min_o"`
-}
 
-func DefaultConfig(mcap float64) PDMConfig {
-	return PDMConfig{
-		PhiTarget:     0.618,
-		BandLow:       0.60,
-		BandHigh:      0.62,
-		BurnBase:      0.000618,
-		BurnVelocityK: 0.1,
-		MinS:          1e-9 * mcap,
-		MinO:          1e-6,
-	}
-}
+	"pdm-personal/pdm"
+)
 
-func ValidatePDMConfig(cfg PDMConfig, mcap float64) error {
-	if cfg.PhiTarget <= 0 || cfg.PhiTarget >= 1 {
-		return fmt.Errorf("phi_target must be in (0, 1), got %f", cfg.PhiTarget)
-	}
-	if cfg.BandLow <= 0 || cfg.BandHigh <= 0 {
-		return fmt.Errorf("band_low and band_high must be > 0")
-	}
-	if cfg.BandLow >= cfg.BandHigh {
-		return fmt.Errorf("band_low must be < band_high, got %f >= %f", cfg.BandLow, cfg.BandHigh)
-	}
-	if cfg.BandLow > cfg.PhiTarget || cfg.PhiTarget > cfg.BandHigh {
-		return fmt.Errorf("band_low <= phi_target <= band_high required, got %f <= %f <= %f violated", cfg.BandLow, cfg.PhiTarget, cfg.BandHigh)
-	}
-	if mcap <= 0 {
-		return fmt.Errorf("mcap must be > 0")
-	}
-	if cfg.MinS <= 0 {
-		return fmt.Errorf("min_s must be > 0")
-	}
-	if cfg.MinO <= 0 {
-		return fmt.Errorf("min_o must be > 0")
-	}
-	return nil
-}
+// ── PDM Core ───────────────────────────────────────────────────────────
+// StepPDM, PDMConfig and StepTrace live in the importable pdm package so
+// that the server, the simulation harness and embedders share one copy.
 
-type StepTrace struct {
-	Timestamp     time.Time `json:"timestamp"`
-	SPrev         float64   `json:"s_prev"`
-	Oi            float64   `json:"o_i"`
-	VTotal        float64   `json:"v_total"`
-	MCap          float64   `json:"m_cap"`
-	PhiTarget     float64   `json:"phi_target"`
-	BandLow       float64   `json:"band_low"`
-	BandHigh      float64   `json:"band_high"`
-	BurnBase      float64   `json:"burn_base"`
-	BurnVelocityK float64   `json:"burn_velocity_k"`
-
-	Velocity   float64 `json:"velocity"`
-	BurnRate   float64 `json:"burn_rate"`
-	BurnAmount float64 `json:"burn_amount"`
-	STemp      float64 `json:"s_temp"`
-	L          float64 `json:"l"`
-	MintRaw    float64 `json:"mint_raw"`
-	MintDamped float64 `json:"mint_damped"`
-	Delta      float64 `json:"delta"`
-	SNew       float64 `json:"s_new"`
-
-	ClampedS      bool   `json:"clamped_s"`
-	ClampedCap    bool   `json:"clamped_cap"`
-	Error         string `json:"error,omitempty"`
-	HashChainRoot string `json:"hash_chain_root"`
+type PoolState struct {
+	S       float64
+	MCap    float64
+	Config  pdm.PDMConfig
+	History []pdm.StepTrace `json:"history,omitempty"`
+	// HistoryBaseRoot is the chain root preceding the journal's first entry
+	// in pools migrated from a pre-journal state.json whose history had
+	// been trimmed: the root that state.json recorded, or, if it was trimmed
+	// before roots were recorded, HistoryBase's. Journals started fresh
+	// chain from "".
+	HistoryBaseRoot string `json:"history_base_root,omitempty"`
+	// HistoryBase is the oldest trace of a history trimmed before base roots
+	// were recorded. Its own link cannot be checked, so migration keeps it
+	// out of the journal and trusts its root, unverified, as the anchor.
+	HistoryBase *pdm.StepTrace `json:"history_base,omitempty"`
+
+	// GenesisRoot is the root of the pool's genesis record, which the first
+	// journaled step chains from. Empty for pools that predate genesis.
+	GenesisRoot string `json:"genesis_root,omitempty"`
+
+	// Journal holds every chain entry (steps and parameter changes) in
+	// order; History is its step subset. Neither is written to state.json.
+	Journal []pdm.Entry `json:"-"`
+
+	// Snapshot bookkeeping: the journal length and head root the snapshot
+	// was derived from. A mismatch on startup means the snapshot is stale.
+	JournalEntries int    `json:"journal_entries"`
+	HeadRoot       string `json:"head_root"`
 }
 
-func StepPDM(sPrev, oi, vtotal, mcap float64, prevHashChainRoot string, cfg PDMConfig) (float64, StepTrace) {
-	trace := StepTrace{
-		Timestamp:     time.Now().UTC(),
-		SPrev:         sPrev,
-		Oi:            oi,
-		VTotal:        vtotal,
-		MCap:          mcap,
-		PhiTarget:     cfg.PhiTarget,
-		BandLow:       cfg.BandLow,
-		BandHigh:      cfg.BandHigh,
-		BurnBase:      cfg.BurnBase,
-		BurnVelocityK: cfg.BurnVelocityK,
-	}
+// Pool is one pool served by the process: its configuration, chain, state
+// and telemetry. Each pool keeps its files in its own data directory and
+// steps on its own schedule.
+type Pool struct {
+	Spec    PoolSpec
+	dataDir string
 
-	if mcap <= 0 {
-		trace.Error = "M_cap must be > 0"
-		return sPrev, trace
-	}
-	if oi < cfg.MinO {
-		oi = cfg.MinO
-		trace.Oi = oi
-	}
-	sSafe := math.Max(sPrev, cfg.MinS)
+	mu      sync.RWMutex // guards state, totals, genesis, loaded and pending
+	state   PoolState
+	totals  chainTotals // chain counters for /metrics
+	genesis *pdm.Genesis
+	loaded  bool
+	pending []PendingParamChange // by effective date, then submission
+	halted  string               // why the runner stopped (telemetry.on_failure abort)
 
-	velocity := vtotal / sSafe
-	deviation := velocity - cfg.PhiTarget
-	burnRate := 1.0 - cfg.BurnVelocityK*deviation
-	if burnRate < 0 {
-		burnRate = 0
-	}
-	burnAmount := cfg.BurnBase * burnRate * vtotal
-	sTemp := sPrev - burnAmount
+	quarantine *Quarantined // step held by the sanity gate, awaiting confirmation
 
-	if sTemp < 0 {
-		trace.ClampedS = true
-		sTemp = 0
-	}
+	telemetrySource                 // the pool's own source
+	quorum          []*quorumMember // telemetry.mode quorum
+	nonces          nonceCache      // signed submissions accepted recently
 
-	trace.Velocity = velocity
-	trace.BurnRate = burnRate
-	trace.BurnAmount = burnAmount
-	trace.STemp = sTemp
+	stepMu  sync.Mutex  // held while the runner, a backfill or an operator runs steps
+	events  eventHub    // Server-Sent Events subscribers and backlog
+	metrics poolMetrics // telemetry counters and step timings for /metrics
 
-	l := sTemp / oi
-	trace.L = l
+	log *log.Logger // prefixes every line with the pool id
+}
 
-	var delta float64
-	if l < cfg.BandLow {
-		mintRaw := cfg.PhiTarget*oi - sTemp
-		if mintRaw < 0 {
-			mintRaw = 0
+// newPool prepares a pool from its spec; call loadState before serving it.
+func newPool(spec PoolSpec) *Pool {
+	p := &Pool{
+		Spec:    spec,
+		dataDir: spec.DataDir,
+		log:     log.New(os.Stderr, "["+spec.ID+"] ", log.LstdFlags|log.Lmsgprefix),
+	}
+	loc := scheduleLocation(spec.Schedule)
+	p.telemetrySource.init(spec.Telemetry, loc)
+	if q := spec.Telemetry.Quorum; q != nil && spec.Telemetry.Mode == "quorum" {
+		for _, src := range q.Sources {
+			m := &quorumMember{name: src.Name, cfg: src.TelemetryConfig}
+			m.init(src.TelemetryConfig, loc)
+			p.quorum = append(p.quorum, m)
 		}
-		damping := math.Pow(cfg.PhiTarget, sTemp/mcap)
-		delta = mintRaw * damping
-		trace.MintRaw = mintRaw
-		trace.MintDamped = delta
-	} else if l >= cfg.BandHigh {
-		delta = 0
-	}
-
-	sNew := sTemp + delta
-	if sNew > mcap {
-		trace.ClampedCap = true
-		delta = mcap - sTemp
-		sNew = mcap
 	}
-
-	trace.Delta = delta
-	trace.SNew = sNew
-
-	// Audit hash chain (chained SHA256: previous hash + trace JSON)
-	traceJSON, _ := json.Marshal(trace)
-	h := sha256.New()
-	h.Write([]byte(prevHashChainRoot + string(traceJSON)))
-	trace.HashChainRoot = fmt.Sprintf("%x", h.Sum(nil))
-
-	return sNew, trace
-}
-
-type PoolState struct {
-	S       float64
-	MCap    float64
-	Config  PDMConfig
-	History []StepTrace `json:"history"`
-}
-
-var (
-	state       PoolState
-	stateMu     sync.RWMutex
-	stateLoaded bool
-)
-
-const dataDir = "./data"
-
-func init() {
-	os.MkdirAll(dataDir, 0755)
-	loadState()
+	return p
 }
 
-func persist(trace StepTrace) {
-	stateMu.Lock()
-	state.History = append(state.History, trace)
-	if len(state.History) > 365 {
-		state.History = state.History[len(state.History)-365:]
-	}
-	stateMu.Unlock()
+// persist commits a completed step. The journal append is the point of no
+// return: if it fails the step is discarded and the in-memory state is left
+// untouched. history.csv and state.json are derived views written afterwards.
+func (p *Pool) persist(trace pdm.StepTrace) error {
+	entry := pdm.Entry{Step: &trace}
+	if err := appendJournal(p.dataDir+"/"+journalFile, entry); err != nil {
+		return fmt.Errorf("journal append: %v", err)
+	}
+
+	p.mu.Lock()
+	p.state.S = trace.SupplyAfter()
+	p.state.Journal = append(p.state.Journal, entry)
+	p.state.History = append(p.state.History, trace)
+	p.totals.add(entry)
+	p.state.JournalEntries = len(p.state.Journal)
+	p.state.HeadRoot = trace.HashChainRoot
+	p.mu.Unlock()
+	p.events.publish(eventStep, trace)
 
 	// CSV append with header detection
-	csvPath := dataDir + "/history.csv"
+	csvPath := p.dataDir + "/history.csv"
 	writeHeader := false
 
 	// Check if file exists and has content
@@ -239,143 +155,425 @@ func persist(trace StepTrace) {
 
 	f, err := os.OpenFile(csvPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		log.Printf("CSV persist error: %v", err)
+		p.log.Printf("CSV persist error: %v", err)
 	} else {
 		defer f.Close()
 		writer := csv.NewWriter(f)
 
 		// Write header on first write
 		if writeHeader {
-			writer.Write([]string{
-				"timestamp", "oi", "v_total", "s_prev", "s_new", "l_ratio", "clamped_s", "clamped_cap", "error",
-			})
-		}
-
-		writer.Write([]string{
-			trace.Timestamp.Format("2006-01-02 15:04:05"),
-			strconv.FormatFloat(trace.Oi, 'f', 6, 64),
-			strconv.FormatFloat(trace.VTotal, 'f', 6, 64),
-			strconv.FormatFloat(trace.SPrev, 'f', 6, 64),
-			strconv.FormatFloat(trace.SNew, 'f', 6, 64),
-			strconv.FormatFloat(trace.L, 'f', 4, 64),
-			fmt.Sprintf("%t", trace.ClampedS),
-			fmt.Sprintf("%t", trace.ClampedCap),
-			trace.Error,
-		})
+			writer.Write(historyCSVHeader)
+		}
+
+		writer.Write(historyCSVRecord(trace))
 		writer.Flush()
 		if err := writer.Error(); err != nil {
-			log.Printf("CSV writer error: %v", err)
+			p.log.Printf("CSV writer error: %v", err)
 		}
 	}
 
-	// Atomic state JSON save (temp + rename)
-	stateMu.RLock()
-	stateJSON, _ := json.Marshal(state)
-	stateMu.RUnlock()
-	tmpFile := dataDir + "/state.json.tmp"
+	p.saveSnapshot()
+	return nil
+}
+
+// commitEntry appends a parameter change or skipped step to the journal and
+// applies it to the in-memory state. Like persist, the journal append is the
+// point of no return. Steps go through persist, which also writes history.csv.
+func (p *Pool) commitEntry(entry pdm.Entry) error {
+	if err := appendJournal(p.dataDir+"/"+journalFile, entry); err != nil {
+		return fmt.Errorf("journal append: %v", err)
+	}
+
+	p.mu.Lock()
+	if pc := entry.ParamChange; pc != nil {
+		p.state.Config = pc.New
+	}
+	p.state.Journal = append(p.state.Journal, entry)
+	p.state.JournalEntries = len(p.state.Journal)
+	p.state.HeadRoot = entry.Root()
+	p.totals.add(entry)
+	p.mu.Unlock()
+	if pc := entry.ParamChange; pc != nil {
+		p.events.publish(eventConfig, map[string]interface{}{"action": "applied", "id": pc.ID, "param_change": pc})
+	}
+
+	p.saveSnapshot()
+	return nil
+}
+
+// saveSnapshot atomically writes state.json (temp + rename). The snapshot
+// omits History; the journal is the system of record for traces.
+func (p *Pool) saveSnapshot() {
+	p.mu.RLock()
+	snap := p.state
+	snap.History = nil
+	stateJSON, _ := json.Marshal(snap)
+	p.mu.RUnlock()
+	tmpFile := p.dataDir + "/state.json.tmp"
 	if err := os.WriteFile(tmpFile, stateJSON, 0644); err != nil {
-		log.Printf("State JSON temp write error: %v", err)
+		p.log.Printf("State JSON temp write error: %v", err)
 		return
 	}
-	if err := os.Rename(tmpFile, dataDir+"/state.json"); err != nil {
-		log.Printf("State JSON rename error: %v", err)
+	if err := os.Rename(tmpFile, p.dataDir+"/state.json"); err != nil {
+		p.log.Printf("State JSON rename error: %v", err)
 	}
 }
 
-func loadState() {
-	stateMu.Lock()
-	defer stateMu.Unlock()
-	data, err := os.ReadFile(dataDir + "/state.json")
-	if err == nil {
-		if json.Unmarshal(data, &state) == nil {
-			stateLoaded = true
-			log.Printf("Loaded state: S=%.2f, History=%d entries", state.S, len(state.History))
+// legacyHistoryLimit is how many traces a pre-journal state.json kept.
+const legacyHistoryLimit = 365
+
+// loadState restores the pool from the journal, using state.json only as a
+// shortcut for the fields traces do not carry. A missing or stale snapshot
+// is rebuilt by replaying the journal; a pre-journal state.json with an
+// embedded history is migrated into a new journal.
+func (p *Pool) loadState() {
+	// A migration's anchor is only in memory until the snapshot is written.
+	migrated := false
+	defer func() {
+		if migrated {
+			p.saveSnapshot()
+		}
+	}()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var snap PoolState
+	haveSnap := false
+	if data, err := os.ReadFile(p.dataDir + "/state.json"); err == nil {
+		if err := json.Unmarshal(data, &snap); err == nil {
+			haveSnap = true
+		} else {
+			p.log.Printf("Ignoring unreadable state.json: %v", err)
+		}
+	}
+
+	if g, err := readGenesis(p.dataDir + "/" + genesisFile); err == nil {
+		p.genesis = g
+	} else if !os.IsNotExist(err) {
+		p.log.Fatalf("Genesis read error: %v", err)
+	}
+
+	journalPath := p.dataDir + "/" + journalFile
+	if err := repairJournalTail(journalPath); err != nil {
+		p.log.Fatalf("Journal repair error: %v", err)
+	}
+	entries, err := readJournal(journalPath)
+	if err != nil && !os.IsNotExist(err) {
+		p.log.Fatalf("Journal read error: %v", err)
+	}
+
+	if os.IsNotExist(err) && haveSnap && len(snap.History) > 0 {
+		// Legacy layout: history lived inside state.json (possibly trimmed).
+		history := snap.History
+		if first := history[0]; snap.HistoryBaseRoot == "" && len(history) >= legacyHistoryLimit && pdm.HashTrace("", first) != first.HashChainRoot {
+			snap.HistoryBase = &first
+			snap.HistoryBaseRoot = first.HashChainRoot
+			history = history[1:]
+			p.log.Printf("WARNING: state.json history was trimmed without a base root; the trace at %s cannot be verified and anchors the journal", first.Timestamp.Format(time.RFC3339))
+		}
+		entries = pdm.StepEntries(history)
+		for _, e := range entries {
+			if err := appendJournal(journalPath, e); err != nil {
+				p.log.Fatalf("Journal migration error: %v", err)
+			}
+		}
+		migrated = true
+		p.log.Printf("Migrated %d traces from state.json into %s", len(entries), journalFile)
+	}
+
+	if len(entries) == 0 {
+		if haveSnap {
+			p.state = snap
+			p.loaded = true
+			p.log.Printf("Loaded state: S=%.2f, no steps journaled yet", p.state.S)
+			return
+		}
+		if p.genesis != nil {
+			p.state = PoolState{S: p.genesis.InitialS, MCap: p.genesis.MCap, Config: p.genesis.Config, GenesisRoot: p.genesis.HashChainRoot}
+			p.loaded = true
+			p.log.Printf("Loaded state from genesis %s: S=%.2f, no steps journaled yet", p.genesis.HashChainRoot[:12], p.state.S)
 			return
 		}
+		// No state – bootstrap in main()
+		p.log.Println("No existing state – will bootstrap from config")
+		return
+	}
+
+	head := entries[len(entries)-1].Root()
+	p.state = snap
+	p.state.Journal = entries
+	p.state.History = pdm.Steps(entries)
+	p.totals = tallyJournal(entries)
+	if !haveSnap || snap.JournalEntries != len(entries) || snap.HeadRoot != head {
+		if haveSnap {
+			p.log.Printf("state.json is stale (snapshot %d entries, journal %d) – rebuilding from journal", snap.JournalEntries, len(entries))
+		} else {
+			p.log.Printf("state.json missing – rebuilding from journal")
+		}
+		p.rebuildFromJournal(haveSnap)
+	}
+	if p.genesis != nil {
+		p.state.GenesisRoot = p.genesis.HashChainRoot
+	} else {
+		p.log.Printf("WARNING: no %s – chain predates genesis records and is anchored at %q", genesisFile, p.state.HistoryBaseRoot)
+	}
+	p.state.JournalEntries = len(entries)
+	p.state.HeadRoot = head
+	p.loaded = true
+	p.log.Printf("Loaded state: S=%.2f, History=%d entries", p.state.S, len(p.state.History))
+}
+
+// rebuildFromJournal derives S, MCap and Config from state.Journal. The
+// supply is the last step's outcome; the parameters are those of the last
+// parameter change, else the genesis, else (without a snapshot) the ones
+// recorded in the last trace. Callers hold p.mu.
+func (p *Pool) rebuildFromJournal(haveSnap bool) {
+	if p.genesis != nil {
+		p.state.S = p.genesis.InitialS
+		p.state.MCap = p.genesis.MCap
+		p.state.Config = p.genesis.Config
+	}
+	var lastStep *pdm.StepTrace
+	var lastChange *pdm.ParamChange
+	for _, e := range p.state.Journal {
+		if e.Step != nil {
+			lastStep = e.Step
+		} else if e.ParamChange != nil {
+			lastChange = e.ParamChange
+		}
+	}
+	if lastStep != nil {
+		p.state.S = lastStep.SupplyAfter()
+		p.state.MCap = lastStep.MCap
+		if p.genesis == nil && !haveSnap {
+			p.state.Config = configFromTrace(*lastStep)
+		}
+	}
+	if lastChange != nil {
+		p.state.Config = lastChange.New
+	}
+}
+
+// chainAnchor is the root the first journaled step chains from: the
+// genesis root, or for older pools the legacy trimmed-history base root.
+// Callers hold p.mu.
+func (p *Pool) chainAnchor() string {
+	if p.state.GenesisRoot != "" {
+		return p.state.GenesisRoot
+	}
+	return p.state.HistoryBaseRoot
+}
+
+// headRoot is the root the next chain entry links to. Callers hold p.mu.
+func (p *Pool) headRoot() string {
+	if len(p.state.Journal) == 0 {
+		return p.chainAnchor()
 	}
-	// No state – bootstrap in main()
-	log.Println("No existing state – will bootstrap from config")
+	return p.state.HeadRoot
 }
 
 var healthy int32 = 1
 
-func stateHandler(w http.ResponseWriter, r *http.Request) {
-	stateMu.RLock()
-	defer stateMu.RUnlock()
+// recentHistoryLen is how many of the latest steps /pdm/v1/state returns,
+// dashboard.show_history_days. The full history is paged by /pdm/v1/history.
+func recentHistoryLen() int {
+	if cfgFile != nil && cfgFile.Dashboard.ShowHistoryDays > 0 {
+		return cfgFile.Dashboard.ShowHistoryDays
+	}
+	return 30
+}
+
+func (p *Pool) stateHandler(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	recent := p.state.History
+	if n := recentHistoryLen(); len(recent) > n {
+		recent = recent[len(recent)-n:]
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(struct {
-		S       float64     `json:"s_current"`
-		MCap    float64     `json:"m_cap"`
-		Latest  StepTrace   `json:"latest_trace,omitempty"`
-		History []StepTrace `json:"history,omitempty"`
+		S       float64         `json:"s_current"`
+		MCap    float64         `json:"m_cap"`
+		Latest  pdm.StepTrace   `json:"latest_trace,omitempty"`
+		History []pdm.StepTrace `json:"history,omitempty"`
 	}{
-		S:    state.S,
-		MCap: state.MCap,
-		Latest: func() StepTrace {
-			if len(state.History) > 0 {
-				return state.History[len(state.History)-1]
+		S:    p.state.S,
+		MCap: p.state.MCap,
+		Latest: func() pdm.StepTrace {
+			if len(p.state.History) > 0 {
+				return p.state.History[len(p.state.History)-1]
 			}
-			return StepTrace{}
+			return pdm.StepTrace{}
 		}(),
-		History: state.History,
+		History: recent,
 	})
 }
 
-func configHandler(w http.ResponseWriter, r *http.Request) {
+// auditVerifyHandler recomputes the hash chain over the journaled history
+// and checks the accounting identity of every step.
+func (p *Pool) auditVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "only GET allowed")
+		return
+	}
+
+	// Copy under the lock; recomputation runs without blocking the runner.
+	p.mu.RLock()
+	journal := append([]pdm.Entry(nil), p.state.Journal...)
+	anchor := p.chainAnchor()
+	g := p.genesis
+	p.mu.RUnlock()
+
+	report := struct {
+		pdm.ChainReport
+		Genesis           *pdm.Genesis    `json:"genesis,omitempty"`
+		GenesisViolations []pdm.Violation `json:"genesis_violations,omitempty"`
+	}{ChainReport: pdm.VerifyEntries(journal, anchor), Genesis: g}
+	if g != nil {
+		report.GenesisViolations = pdm.CheckGenesis(*g, journal)
+		if len(report.GenesisViolations) > 0 {
+			report.Valid = false
+			p.log.Printf("AUDIT: %d genesis violations", len(report.GenesisViolations))
+		}
+	}
+	if !report.Valid {
+		if report.FirstBreak != nil {
+			p.log.Printf("AUDIT: hash chain broken at step %d (expected %s, got %s)",
+				report.FirstBreak.Index, report.FirstBreak.Expected, report.FirstBreak.Actual)
+		}
+		if len(report.AccountingErrors) > 0 {
+			p.log.Printf("AUDIT: %d accounting identity violations", len(report.AccountingErrors))
+		}
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// auditExportHandler returns the journaled history as a pdm.Bundle that
+// `pdm-personal verify` can audit offline.
+func (p *Pool) auditExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "only GET allowed")
+		return
+	}
+
+	p.mu.RLock()
+	bundle := pdm.Bundle{
+		Version:    pdm.Version,
+		PoolName:   p.Spec.Name,
+		MCap:       p.state.MCap,
+		Config:     p.state.Config,
+		Genesis:    p.genesis,
+		AnchorRoot: p.chainAnchor(),
+		ExportedAt: time.Now().UTC(),
+		Traces:     append([]pdm.Entry(nil), p.state.Journal...),
+	}
+	p.mu.RUnlock()
+
+	w.Header().Set("Content-Disposition", `attachment; filename="pdm-bundle.json"`)
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+func (p *Pool) configHandler(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	pdmCfg := p.state.Config
+	p.mu.RUnlock()
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"pool_name":                cfgFile.Pool.Name,
-		"unit":                     cfgFile.Resource.Unit,
-		"show_history_days":        cfgFile.Dashboard.ShowHistoryDays,
-		"schedule_run_time":        cfgFile.Schedule.RunTime,
-		"schedule_timezone":        cfgFile.Schedule.Timezone,
-		"telemetry_auth_required":  cfgFile.Telemetry.AuthToken != "",
+		"pool_id":                    p.Spec.ID,
+		"pool_name":                  p.Spec.Name,
+		"unit":                       p.Spec.Resource.Unit,
+		"show_history_days":          cfgFile.Dashboard.ShowHistoryDays,
+		"schedule_run_time":          p.Spec.Schedule.RunTime,
+		"schedule_timezone":          p.Spec.Schedule.Timezone,
+		"telemetry_auth_required":    p.Spec.Telemetry.AuthToken != "",
+		"telemetry_signing_required": p.Spec.Telemetry.Signing != nil,
+		"phi_target":                 pdmCfg.PhiTarget,
+		"band_low":                   pdmCfg.BandLow,
+		"band_high":                  pdmCfg.BandHigh,
+		"pdm_config":                 pdmCfg,
 	})
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	if atomic.LoadInt32(&healthy) == 1 {
+		halted := map[string]string{}
+		quarantined := map[string]time.Time{}
+		for _, p := range pools {
+			p.mu.RLock()
+			if p.halted != "" {
+				halted[p.Spec.ID] = p.halted
+			}
+			if p.quarantine != nil {
+				quarantined[p.Spec.ID] = p.quarantine.ScheduledAt
+			}
+			p.mu.RUnlock()
+		}
+		if len(halted) > 0 || len(quarantined) > 0 {
+			resp := map[string]interface{}{"status": "degraded"}
+			if len(halted) > 0 {
+				resp["halted_pools"] = halted
+			}
+			if len(quarantined) > 0 {
+				resp["quarantined_pools"] = quarantined
+			}
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
 		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 	} else {
 		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting_down"})
 	}
 }
 
-var telemetryMode string
-
-// fetchTelemetryValues returns (Oi, V) for the current mode.
-// In CSV mode, it reads the file once per step.
-func fetchTelemetryValues() (float64, float64) {
-	switch telemetryMode {
-	case "manual":
-		oi, _ := manualTelemetry.FetchOi()
-		v, _ := manualTelemetry.FetchV()
-		return oi, v
-	case "csv":
-		oi, v, _ := csvTelemetry.FetchToday()
-		return oi, v
-	case "webhook":
-		oi, _ := webhookTelemetry.FetchOi()
-		v, _ := webhookTelemetry.FetchV()
-		return oi, v
-	default:
-		return 0, 0
+// fetchTelemetryValues returns (Oi, V) for the step scheduled at `at`, or an
+// error if the source has nothing usable for that step's date. Submitted
+// telemetry is also subject to telemetry.max_age. info records the values'
+// provenance and, when they were aggregated from events or several sources
+// or queried from Prometheus, how.
+func (p *Pool) fetchTelemetryValues(at time.Time) (oi, v float64, info *pdm.TelemetryInfo, err error) {
+	defer p.metrics.observe(phaseFetch, time.Now())
+	date := at.In(scheduleLocation(p.Spec.Schedule)).Format("2006-01-02")
+	if p.Spec.Telemetry.Mode == "quorum" {
+		oi, v, info, err = p.fetchQuorum(date, at)
+	} else {
+		oi, v, info, err = p.fetch(p.Spec.Telemetry, date, at)
+	}
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	// Same constraints as the POST endpoint and CSV reader. NaN fails
+	// every comparison, so it is refused explicitly.
+	if !isFinite(oi) || !isFinite(v) {
+		return 0, 0, nil, fmt.Errorf("invalid telemetry: Oi and V must be finite (got %g, %g)", oi, v)
 	}
+	if oi <= 0 {
+		return 0, 0, nil, fmt.Errorf("no telemetry: Oi must be > 0 (got %g)", oi)
+	}
+	if v < 0 {
+		return 0, 0, nil, fmt.Errorf("invalid telemetry: V must be >= 0 (got %g)", v)
+	}
+	return oi, v, info, nil
 }
 
-// calculateNextRun computes the next scheduled run time based on config
-func calculateNextRun(cfg *ConfigFile) time.Time {
-	// Load timezone
-	loc, err := time.LoadLocation(cfg.Schedule.Timezone)
+// scheduleLocation returns the schedule timezone, or UTC.
+func scheduleLocation(sched ScheduleConfig) *time.Location {
+	loc, err := time.LoadLocation(sched.Timezone)
 	if err != nil {
-		log.Printf("Invalid timezone '%s', defaulting to UTC: %v", cfg.Schedule.Timezone, err)
-		loc = time.UTC
+		log.Printf("Invalid timezone '%s', defaulting to UTC: %v", sched.Timezone, err)
+		return time.UTC
 	}
+	return loc
+}
+
+// calculateNextRun computes the next scheduled run time of a pool's schedule
+func calculateNextRun(sched ScheduleConfig) time.Time {
+	loc := scheduleLocation(sched)
 
 	// Parse run time
-	runTimeParts, err := time.Parse("15:04", cfg.Schedule.RunTime)
+	runTimeParts, err := time.Parse("15:04", sched.RunTime)
 	if err != nil {
-		log.Printf("Invalid run_time '%s', defaulting to 00:00: %v", cfg.Schedule.RunTime, err)
+		log.Printf("Invalid run_time '%s', defaulting to 00:00: %v", sched.RunTime, err)
 		runTimeParts, _ = time.Parse("15:04", "00:00")
 	}
 
@@ -394,40 +592,255 @@ func calculateNextRun(cfg *ConfigFile) time.Time {
 	return next
 }
 
-func dailyRunner() {
-	prevRoot := ""
-	if stateLoaded && len(state.History) > 0 {
-		prevRoot = state.History[len(state.History)-1].HashChainRoot
-	}
-
-	for {
-		next := calculateNextRun(cfgFile)
+func (p *Pool) dailyRunner() {
+	// Account for scheduled steps missed while the server was down. A pool
+	// with nothing on its chain yet leaves that to its first scheduled run,
+	// so history since a backdated genesis can be backfilled first.
+	p.stepMu.Lock()
+	p.mu.RLock()
+	empty := len(p.state.Journal) == 0
+	p.mu.RUnlock()
+	if !empty {
+		p.catchUp(time.Now())
+	}
+	p.stepMu.Unlock()
+	p.noteHealth()
+
+	for !p.isHalted() {
+		next := calculateNextRun(p.Spec.Schedule)
 		sleepDuration := time.Until(next)
-		log.Printf("Next PDM step scheduled for: %s (sleeping %v)", next.Format("2006-01-02 15:04:05 MST"), sleepDuration.Round(time.Minute))
+		p.log.Printf("Next PDM step scheduled for: %s (sleeping %v)", next.Format("2006-01-02 15:04:05 MST"), sleepDuration.Round(time.Minute))
 		time.Sleep(sleepDuration)
 
-		oi, vtotal := fetchTelemetryValues()
+		// A suspended host can oversleep past whole slots.
+		p.stepMu.Lock()
+		p.catchUp(next)
+		if !p.isHalted() {
+			p.runScheduled(next)
+		}
+		p.stepMu.Unlock()
+	}
+	p.mu.RLock()
+	p.log.Printf("Runner halted: %s", p.halted)
+	p.mu.RUnlock()
+}
+
+func (p *Pool) isHalted() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.halted != ""
+}
+
+// runScheduled fetches telemetry and runs the step scheduled at `at`,
+// applying telemetry.on_failure if the source fails and quarantining values
+// that fail telemetry.sanity.
+func (p *Pool) runScheduled(at time.Time) {
+	p.runScheduledCombining(at, nil)
+}
+
+// runScheduledCombining is runScheduled with the V of earlier missed slots
+// added to the step's V. The sanity gate sees the slot's own values. If the
+// slot's telemetry fails, the combined V is dropped with a warning; the
+// slots it came from are already recorded as skipped.
+func (p *Pool) runScheduledCombining(at time.Time, combined []pdm.CombinedSlot) {
+	defer p.noteHealth()
+	p.expireQuarantine(at)
+	oi, vtotal, info, err := p.fetchTelemetryValues(at)
+	if err == nil {
+		reasons := p.sanityCheck(oi, vtotal)
+		if len(combined) > 0 {
+			annotated := pdm.TelemetryInfo{}
+			if info != nil {
+				annotated = *info
+			}
+			annotated.Combined = combined
+			info = &annotated
+			for _, c := range combined {
+				vtotal += c.V
+			}
+		}
+		if len(reasons) > 0 {
+			p.log.Printf("WARNING: telemetry for step at %s failed the sanity gate and is quarantined: %s", at.Format(time.RFC3339), strings.Join(reasons, "; "))
+			p.quarantineStep(at, oi, vtotal, info, reasons)
+			return
+		}
+		p.step(at, oi, vtotal, info)
+		return
+	}
+
+	policy := p.Spec.Telemetry.OnFailure
+	p.log.Printf("WARNING: telemetry unavailable for step at %s (on_failure %s): %v", at.Format(time.RFC3339), policy, err)
+	if len(combined) > 0 {
+		p.log.Printf("WARNING: V of %d missed slot(s) could not be combined into the step at %s and is dropped", len(combined), at.Format(time.RFC3339))
+	}
+	switch policy {
+	case "hold":
+		p.mu.RLock()
+		last, ok := p.lastGoodTrace()
+		p.mu.RUnlock()
+		if !ok {
+			p.skip(at, pdm.SkipPolicyTelemetrySkip, "hold: no earlier step to hold values from; "+err.Error())
+			return
+		}
+		heldFrom := last.Timestamp
+		if last.Telemetry != nil && last.Telemetry.HeldFrom != nil {
+			heldFrom = *last.Telemetry.HeldFrom
+		}
+		p.step(at, last.Oi, last.VTotal, &pdm.TelemetryInfo{
+			Policy: policy, Reason: err.Error(), HeldFrom: &heldFrom,
+			Provenance: &pdm.Provenance{Mode: p.Spec.Telemetry.Mode, Source: "hold"},
+		})
+	case "abort":
+		p.skip(at, pdm.SkipPolicyTelemetryAbort, err.Error())
+		p.mu.Lock()
+		p.halted = fmt.Sprintf("telemetry failure at %s: %v", at.Format(time.RFC3339), err)
+		p.mu.Unlock()
+		sendAlert(p, "telemetry_abort", fmt.Sprintf("step at %s aborted and pool halted until restart: %v", at.Format(time.RFC3339), err))
+	default:
+		p.skip(at, pdm.SkipPolicyTelemetrySkip, err.Error())
+	}
+}
+
+// lastGoodTrace returns the latest step that ran without error. Callers
+// hold p.mu.
+func (p *Pool) lastGoodTrace() (pdm.StepTrace, bool) {
+	for i := len(p.state.History) - 1; i >= 0; i-- {
+		if tr := p.state.History[i]; tr.Error == "" {
+			return tr, true
+		}
+	}
+	return pdm.StepTrace{}, false
+}
+
+// step runs and commits the step scheduled at `at`. info, if set, records
+// how Oi and V were obtained. It returns an error if the step was discarded.
+func (p *Pool) step(at time.Time, oi, vtotal float64, info *pdm.TelemetryInfo) error {
+	defer p.metrics.observe(phaseStep, time.Now())
+	// Observability: warn if V is zero
+	if vtotal == 0 {
+		p.log.Printf("WARNING: V is zero — no burn will occur this step")
+	}
+
+	// Parameter changes due by this step's date are chained first, so
+	// the step records the parameters that governed it.
+	p.applyDueParamChanges(at)
+
+	p.mu.RLock()
+	// Stamp the trace with the scheduled run time, not the wake-up time,
+	// so the chain can be recomputed from telemetry and the schedule alone.
+	prevRoot := p.headRoot()
+	newS, trace := pdm.StepPDMAt(at, p.state.S, oi, vtotal, p.state.MCap, prevRoot, p.state.Config)
+	p.mu.RUnlock()
+	// Every trace records its provenance, with the values handed to the
+	// control law as the raw inputs.
+	annotated := pdm.TelemetryInfo{}
+	if info != nil {
+		annotated = *info
+	}
+	prov := pdm.Provenance{Mode: p.Spec.Telemetry.Mode}
+	if annotated.Provenance != nil {
+		prov = *annotated.Provenance
+	}
+	prov.OiRaw, prov.VRaw = oi, vtotal
+	annotated.Provenance = &prov
+	trace.Annotate(prevRoot, &annotated)
+
+	if err := p.persist(trace); err != nil {
+		p.log.Printf("ERROR: PDM step discarded, state unchanged: %v", err)
+		return err
+	}
+	p.log.Printf("PDM step completed → L=%.4f  S=%.2f", trace.L, newS)
+
+	// Submissions for earlier dates can no longer be used.
+	for _, s := range p.stores() {
+		if err := s.Prune(at.In(scheduleLocation(p.Spec.Schedule)).Format("2006-01-02")); err != nil {
+			p.log.Printf("Telemetry submissions write error: %v", err)
+		}
+	}
+	return nil
+}
+
+// open loads the pool from its data directory, bootstrapping new pools
+// from the spec and sealing their genesis record.
+func (p *Pool) open() {
+	os.MkdirAll(p.dataDir, 0755)
+	p.loadState()
+	p.loadPendingChanges()
+	p.loadQuarantine()
+	if err := p.telemetrySource.open(p.Spec.Telemetry, p.dataDir+"/"+submissionsFile); err != nil {
+		p.log.Fatalf("Telemetry source error: %v", err)
+	}
+	for _, m := range p.quorum {
+		if err := m.open(m.cfg, p.dataDir+"/"+m.submissionsFile()); err != nil {
+			p.log.Fatalf("Telemetry source %s error: %v", m.name, err)
+		}
+	}
+	if err := p.nonces.Load(p.dataDir + "/" + noncesFile); err != nil {
+		p.log.Fatalf("Telemetry nonces read error: %v", err)
+	}
+	for _, s := range p.stores() {
+		if n := s.Len(); n > 0 {
+			p.log.Printf("Loaded %d telemetry submission(s)", n)
+		}
+	}
 
-		// Observability: warn if telemetry is missing or zero
-		if oi == 0 {
-			log.Printf("WARNING: Oi is zero or missing — PDM step will use MinO fallback")
+	// Bootstrap only if no state loaded
+	if !p.loaded {
+		p.state = PoolState{
+			S:      p.Spec.InitialS,
+			MCap:   p.Spec.MCap,
+			Config: p.Spec.PDMConfig(),
 		}
-		if vtotal == 0 {
-			log.Printf("WARNING: V is zero — no burn will occur this step")
+		p.log.Println("Bootstrapped from config.yaml")
+	} else if diff := diffPDMConfig(p.state.Config, p.Spec.PDMConfig()); len(diff) > 0 {
+		// Once a pool has state, its persisted parameters govern the chain.
+		// config.yaml's pdm section only seeds new pools.
+		p.log.Printf("WARNING: config.yaml pdm section differs from persisted pool parameters; persisted values stay in force; submit changes via POST /pdm/v1/pools/%s/params", p.Spec.ID)
+		for _, d := range diff {
+			p.log.Printf("  pdm.%s (persisted -> config.yaml, ignored)", d)
 		}
+	}
 
-		stateMu.Lock()
-		newS, trace := StepPDM(state.S, oi, vtotal, state.MCap, prevRoot, state.Config)
-		state.S = newS
-		prevRoot = trace.HashChainRoot
-		stateMu.Unlock()
+	// Seal a genesis record for new pools so the first step chains from the
+	// bootstrap configuration rather than from an empty root.
+	if p.genesis == nil && len(p.state.History) == 0 {
+		if err := pdm.ValidatePDMConfig(p.state.Config, p.state.MCap); err != nil {
+			p.log.Fatalf("PDMConfig validation error: %v", err)
+		}
+		createdAt := time.Now()
+		if p.Spec.GenesisDate != "" {
+			at, err := genesisTime(p.Spec.Schedule, p.Spec.GenesisDate)
+			if err != nil {
+				p.log.Fatalf("pool.genesis_date error: %v", err)
+			}
+			createdAt = at
+		}
+		g := pdm.NewGenesis(p.Spec.Name, p.Spec.Resource.Unit, p.state.MCap, p.state.S, p.state.Config, createdAt)
+		if err := writeGenesis(p.dataDir+"/"+genesisFile, g); err != nil {
+			p.log.Fatalf("Genesis write error: %v", err)
+		}
+		p.genesis = &g
+		p.state.GenesisRoot = g.HashChainRoot
+		p.saveSnapshot()
+		p.log.Printf("Genesis record sealed: %s", g.HashChainRoot)
+	} else if p.genesis != nil && p.Spec.GenesisDate != "" {
+		if at, err := genesisTime(p.Spec.Schedule, p.Spec.GenesisDate); err == nil && !at.Equal(p.genesis.CreatedAt) {
+			p.log.Printf("WARNING: pool.genesis_date %s ignored; the genesis record sealed at %s stays in force", p.Spec.GenesisDate, p.genesis.CreatedAt.Format(time.RFC3339))
+		}
+	}
 
-		persist(trace)
-		log.Printf("PDM step completed → L=%.4f  S=%.2f", trace.L, newS)
+	// Validate PDM config coherence constraints (Section 3 of whitepaper)
+	if err := pdm.ValidatePDMConfig(p.state.Config, p.state.MCap); err != nil {
+		p.log.Fatalf("PDMConfig validation error: %v", err)
 	}
 }
 
 func main() {
+	// Offline audit: pdm-personal verify <file>
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	atomic.StoreInt32(&healthy, 1)
 
 	// Load user config
@@ -437,31 +850,20 @@ func main() {
 		log.Fatalf("Config error: %v", err)
 	}
 
-	telemetryMode = cfgFile.Telemetry.Mode
-	csvTelemetry.csvPath = cfgFile.Telemetry.CSVPath
-
-	// Bootstrap only if no state loaded
-	if !stateLoaded {
-		state = PoolState{
-			S:      cfgFile.Pool.InitialS,
-			MCap:   cfgFile.Pool.MCap,
-			Config: DefaultConfig(cfgFile.Pool.MCap),
-		}
-		log.Println("Bootstrapped from config.yaml")
-	}
-
-	// Validate PDM config coherence constraints (Section 3 of whitepaper)
-	if err := ValidatePDMConfig(state.Config, state.MCap); err != nil {
-		log.Fatalf("PDMConfig validation error: %v", err)
+	for _, spec := range cfgFile.Pools {
+		p := newPool(spec)
+		p.open()
+		pools = append(pools, p)
 	}
 
-	http.HandleFunc("/pdm/v1/state", stateHandler)
-	http.HandleFunc("/pdm/v1/config", configHandler)
+	registerRoutes(http.DefaultServeMux)
 	http.HandleFunc("/pdm/v1/health", healthHandler)
-	http.HandleFunc("/api/telemetry", telemetryHandler)
+	http.HandleFunc("/metrics", metricsHandler)
 	http.Handle("/", http.FileServer(http.Dir("./web")))
 
-	go dailyRunner()
+	for _, p := range pools {
+		go p.dailyRunner()
+	}
 
 	// Graceful shutdown
 	c := make(chan os.Signal, 1)
@@ -470,22 +872,15 @@ func main() {
 		<-c
 		atomic.StoreInt32(&healthy, 0)
 		// Atomic shutdown save
-		tmpFile := dataDir + "/state.json.tmp"
-		stateMu.RLock()
-		stateJSON, _ := json.Marshal(state)
-		stateMu.RUnlock()
-		if err := os.WriteFile(tmpFile, stateJSON, 0644); err != nil {
-			log.Printf("Shutdown state temp write error: %v", err)
-			return
-		}
-		if err := os.Rename(tmpFile, dataDir+"/state.json"); err != nil {
-			log.Printf("Shutdown state rename error: %v", err)
+		for _, p := range pools {
+			p.publishHealth(poolHealth{Pool: p.Spec.ID, Status: "shutting_down"})
+			p.saveSnapshot()
 		}
 		log.Println("PDM shutting down gracefully – state saved")
 		os.Exit(0)
 	}()
 
-	log.Printf("PDM Personal Edition starting on port %d", cfgFile.Dashboard.Port)
+	log.Printf("PDM Personal Edition starting on port %d (%d pool(s))", cfgFile.Dashboard.Port, len(pools))
 	srv := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfgFile.Dashboard.Port),
 		ReadHeaderTimeout: 5 * time.Second,
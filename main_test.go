@@ -2,13 +2,17 @@ package main
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"pdm-personal/pdm"
 )
 
 // Guardrail test: ensure the audit root field name stays aligned with docs/UI/output.
 func TestStepTraceJSON_FieldName_HashChainRoot(t *testing.T) {
-	tr := StepTrace{HashChainRoot: "abc"}
+	tr := pdm.StepTrace{HashChainRoot: "abc"}
 	b, err := json.Marshal(tr)
 	if err != nil {
 		t.Fatalf("marshal failed: %v", err)
@@ -21,3 +25,55 @@ func TestStepTraceJSON_FieldName_HashChainRoot(t *testing.T) {
 		t.Fatalf("did not expect merkle_root in JSON, got: %s", s)
 	}
 }
+
+// testPool returns a pool with an empty data directory under t.TempDir().
+func testPool(t *testing.T) *Pool {
+	t.Helper()
+	return newPool(PoolSpec{
+		PoolConfig: PoolConfig{Name: "test", MCap: 1000000, InitialS: 618000},
+		ID:         "test",
+		DataDir:    t.TempDir(),
+		Schedule:   ScheduleConfig{RunTime: "00:00", Timezone: "UTC"},
+		Telemetry:  TelemetryConfig{Mode: "manual"},
+	})
+}
+
+func TestAuditVerifyHandler_ReportsBrokenLink(t *testing.T) {
+	history := testTraces(5, "", nil)
+	s := history[4].SNew
+	history[2].Oi = 1
+
+	p := testPool(t)
+	p.state = PoolState{S: s, MCap: p.Spec.MCap, Config: p.Spec.PDMConfig(), History: history, Journal: pdm.StepEntries(history)}
+
+	rec := httptest.NewRecorder()
+	p.auditVerifyHandler(rec, httptest.NewRequest(http.MethodGet, "/pdm/v1/audit/verify", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var rep pdm.ChainReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if rep.Valid || rep.FirstBreak == nil || rep.FirstBreak.Index != 2 {
+		t.Fatalf("expected break at index 2, got %+v", rep)
+	}
+}
+
+func TestStateHandler_ReturnsRecentHistoryOnly(t *testing.T) {
+	p := testPool(t)
+	history := testTraces(recentHistoryLen()+5, "", nil)
+	p.state = PoolState{S: history[len(history)-1].SNew, MCap: p.Spec.MCap, Config: p.Spec.PDMConfig(), History: history}
+
+	rec := httptest.NewRecorder()
+	p.stateHandler(rec, httptest.NewRequest(http.MethodGet, "/pdm/v1/state", nil))
+	var resp struct {
+		History []pdm.StepTrace `json:"history"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(resp.History); n != recentHistoryLen() || resp.History[n-1].HashChainRoot != history[len(history)-1].HashChainRoot {
+		t.Fatalf("expected the latest %d steps, got %d", recentHistoryLen(), n)
+	}
+}
@@ -0,0 +1,331 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/metrics.go
+// Prometheus /metrics exporter for pool and control-law state
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the step duration
+// histograms. Fetches from pull sources can take several retries.
+var durationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60}
+
+// Step phases timed by pdm_step_duration_seconds.
+const (
+	phaseFetch = "fetch" // obtaining Oi and V from the telemetry source
+	phaseStep  = "step"  // computing, sealing and journaling the step
+)
+
+// poolMetrics holds a pool's process-lifetime counters. Everything that can
+// be derived from the chain is kept in chainTotals instead, so those
+// counters survive restarts.
+type poolMetrics struct {
+	mu          sync.Mutex
+	submissions uint64
+	rejections  map[int]uint64 // by HTTP status
+	sanity      uint64         // values failing the sanity gate
+	durations   map[string]*histogram
+}
+
+// chainTotals are the chain counters, summed over the journal when it is
+// loaded and advanced as each entry is committed, so that a scrape does not
+// walk the journal.
+type chainTotals struct {
+	burned, minted, trimmed     float64
+	steps, clampedS, clampedCap float64
+	skipped                     map[string]float64 // by policy
+}
+
+// tallyJournal sums the chain counters over entries.
+func tallyJournal(entries []pdm.Entry) chainTotals {
+	var c chainTotals
+	for _, e := range entries {
+		c.add(e)
+	}
+	return c
+}
+
+// add counts a committed entry.
+func (c *chainTotals) add(entry pdm.Entry) {
+	switch {
+	case entry.Step != nil:
+		c.steps++
+		tr := entry.Step
+		if tr.Error != "" {
+			return
+		}
+		// BurnAmount overstates the burn when S was clamped at zero.
+		c.burned += tr.SPrev - tr.STemp
+		if tr.Delta > 0 {
+			c.minted += tr.Delta
+		} else {
+			c.trimmed -= tr.Delta
+		}
+		if tr.ClampedS {
+			c.clampedS++
+		}
+		if tr.ClampedCap {
+			c.clampedCap++
+		}
+	case entry.Skipped != nil:
+		if c.skipped == nil {
+			c.skipped = map[string]float64{}
+		}
+		c.skipped[entry.Skipped.Policy]++
+	}
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+// submitted counts n accepted telemetry submissions.
+func (m *poolMetrics) submitted(n int) {
+	m.mu.Lock()
+	m.submissions += uint64(n)
+	m.mu.Unlock()
+}
+
+// responded counts a telemetry request answered with an error status as a
+// rejection.
+func (m *poolMetrics) responded(status int) {
+	if status < 400 {
+		return
+	}
+	m.mu.Lock()
+	if m.rejections == nil {
+		m.rejections = map[int]uint64{}
+	}
+	m.rejections[status]++
+	m.mu.Unlock()
+}
+
+// rejectedBySanity counts values that failed the sanity gate, whether the
+// step was quarantined or the slot skipped.
+func (m *poolMetrics) rejectedBySanity() {
+	m.mu.Lock()
+	m.sanity++
+	m.mu.Unlock()
+}
+
+// observe records the duration of a step phase started at start.
+func (m *poolMetrics) observe(phase string, start time.Time) {
+	d := time.Since(start).Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.durations == nil {
+		m.durations = map[string]*histogram{}
+	}
+	h := m.durations[phase]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(durationBuckets)+1)}
+		m.durations[phase] = h
+	}
+	h.counts[sort.SearchFloat64s(durationBuckets, d)]++
+	h.sum += d
+	h.count++
+}
+
+// statusWriter remembers the status of the response written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// exposition collects metric families in the Prometheus text format,
+// keeping each family's samples together in the order first added.
+type exposition struct {
+	order    []string
+	families map[string]*bytes.Buffer
+}
+
+func (e *exposition) add(name, typ, help string, labels []string, value float64) {
+	e.sample(name, name, typ, help, labels, value)
+}
+
+// sample adds a sample named sampleName to family name; they differ for
+// histogram series.
+func (e *exposition) sample(name, sampleName, typ, help string, labels []string, value float64) {
+	if e.families == nil {
+		e.families = map[string]*bytes.Buffer{}
+	}
+	b := e.families[name]
+	if b == nil {
+		b = &bytes.Buffer{}
+		b.WriteString("# HELP " + name + " " + help + "\n# TYPE " + name + " " + typ + "\n")
+		e.families[name] = b
+		e.order = append(e.order, name)
+	}
+	b.WriteString(sampleName)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i] + `="` + escapeLabel(labels[i+1]) + `"`)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteString(" " + formatSample(value) + "\n")
+}
+
+func (e *exposition) writeTo(w *bytes.Buffer) {
+	for _, name := range e.order {
+		w.Write(e.families[name].Bytes())
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func formatSample(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// collect adds the pool's samples to e.
+func (p *Pool) collect(e *exposition) {
+	pool := []string{"pool", p.Spec.ID}
+	with := func(k, v string) []string { return []string{"pool", p.Spec.ID, k, v} }
+
+	p.mu.RLock()
+	s, mcap := p.state.S, p.state.MCap
+	latest, haveLatest := p.lastGoodTrace()
+	c := p.totals
+	skipped := make(map[string]float64, len(c.skipped))
+	for policy, n := range c.skipped {
+		skipped[policy] = n
+	}
+	halted, quarantined := p.halted != "", p.quarantine != nil
+	p.mu.RUnlock()
+
+	e.add("pdm_supply", "gauge", "Current supply S.", pool, s)
+	e.add("pdm_supply_cap", "gauge", "Supply cap MCap.", pool, mcap)
+	if mcap > 0 {
+		e.add("pdm_supply_ratio", "gauge", "Supply as a fraction of the cap, S/MCap.", pool, s/mcap)
+	}
+	if haveLatest {
+		e.add("pdm_l_ratio", "gauge", "L of the latest successful step.", pool, latest.L)
+		e.add("pdm_velocity", "gauge", "Velocity V/S of the latest successful step.", pool, latest.Velocity)
+		e.add("pdm_burn_rate", "gauge", "Burn rate of the latest successful step.", pool, latest.BurnRate)
+		e.add("pdm_last_step_timestamp_seconds", "gauge", "Scheduled time of the latest successful step.", pool, float64(latest.Timestamp.Unix()))
+	}
+	e.add("pdm_halted", "gauge", "1 if the pool's runner is halted by telemetry.on_failure abort.", pool, boolSample(halted))
+	e.add("pdm_quarantined", "gauge", "1 if a step is held by the sanity gate awaiting confirmation.", pool, boolSample(quarantined))
+
+	e.add("pdm_burned_total", "counter", "Supply burned by all steps on the chain, SPrev - STemp.", pool, c.burned)
+	e.add("pdm_minted_total", "counter", "Supply minted by all steps on the chain (positive Delta).", pool, c.minted)
+	e.add("pdm_cap_trimmed_total", "counter", "Supply removed by the cap clamp after MCap was lowered below S (negative Delta).", pool, c.trimmed)
+	e.add("pdm_clamps_total", "counter", "Steps on the chain where a clamp engaged.", with("clamp", "supply_floor"), c.clampedS)
+	e.add("pdm_clamps_total", "counter", "", with("clamp", "cap"), c.clampedCap)
+	e.add("pdm_steps_total", "counter", "Steps on the chain, including ones that recorded an error.", pool, c.steps)
+	for _, policy := range sortedKeys(skipped) {
+		e.add("pdm_steps_skipped_total", "counter", "Scheduled steps recorded as skipped, by policy.", with("policy", policy), skipped[policy])
+	}
+
+	p.metrics.mu.Lock()
+	defer p.metrics.mu.Unlock()
+	e.add("pdm_telemetry_submissions_total", "counter", "Telemetry submissions accepted since the server started, counting backfill rows.", pool, float64(p.metrics.submissions))
+	codes := make([]int, 0, len(p.metrics.rejections))
+	for code := range p.metrics.rejections {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		e.add("pdm_telemetry_rejections_total", "counter", "Telemetry rejected since the server started, by HTTP status, or with reason sanity for values failing the sanity gate.",
+			with("code", strconv.Itoa(code)), float64(p.metrics.rejections[code]))
+	}
+	if p.metrics.sanity > 0 {
+		e.add("pdm_telemetry_rejections_total", "counter", "Telemetry rejected since the server started, by HTTP status, or with reason sanity for values failing the sanity gate.",
+			with("reason", "sanity"), float64(p.metrics.sanity))
+	}
+	for _, phase := range []string{phaseFetch, phaseStep} {
+		h := p.metrics.durations[phase]
+		if h == nil {
+			continue
+		}
+		const name, help = "pdm_step_duration_seconds", "Duration of step phases: fetching telemetry and running the step."
+		var cum uint64
+		for i, le := range durationBuckets {
+			cum += h.counts[i]
+			e.sample(name, name+"_bucket", "histogram", help, []string{"pool", p.Spec.ID, "phase", phase, "le", formatSample(le)}, float64(cum))
+		}
+		e.sample(name, name+"_bucket", "histogram", help, []string{"pool", p.Spec.ID, "phase", phase, "le", "+Inf"}, float64(h.count))
+		e.sample(name, name+"_sum", "histogram", help, with("phase", phase), h.sum)
+		e.sample(name, name+"_count", "histogram", help, with("phase", phase), float64(h.count))
+	}
+}
+
+func boolSample(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// metricsHandler serves every pool's metrics in the Prometheus text
+// exposition format.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "only GET allowed")
+		return
+	}
+	var e exposition
+	for _, p := range pools {
+		p.collect(&e)
+	}
+	var b bytes.Buffer
+	e.writeTo(&b)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(b.Bytes())
+}
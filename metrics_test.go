@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+func TestMetricsHandler_ExposesPoolState(t *testing.T) {
+	p, traces := historyPool(t)
+	p.state.S = traces[len(traces)-1].SNew
+
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		p.telemetryHandler(rec, httptest.NewRequest(http.MethodPost, "/api/telemetry", strings.NewReader(body)))
+		return rec.Code
+	}
+	if code := post(`{"oi": 1000000, "v": 50000}`); code != http.StatusOK {
+		t.Fatalf("expected submission accepted, got %d", code)
+	}
+	if code := post(`{"oi": 0, "v": 50000}`); code != http.StatusBadRequest {
+		t.Fatalf("expected submission rejected, got %d", code)
+	}
+
+	p.metrics.observe(phaseStep, time.Now())
+
+	saved := pools
+	pools = []*Pool{p}
+	defer func() { pools = saved }()
+	rec := httptest.NewRecorder()
+	metricsHandler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := rec.Body.String()
+
+	var minted float64
+	clamps := 0
+	for _, tr := range traces {
+		if tr.Delta > 0 {
+			minted += tr.Delta
+		}
+		if tr.ClampedCap {
+			clamps++
+		}
+	}
+	for _, want := range []string{
+		"# TYPE pdm_supply gauge\n",
+		fmt.Sprintf("pdm_supply{pool=\"test\"} %s\n", formatSample(p.state.S)),
+		fmt.Sprintf("pdm_l_ratio{pool=\"test\"} %s\n", formatSample(traces[5].L)),
+		fmt.Sprintf("pdm_minted_total{pool=\"test\"} %s\n", formatSample(minted)),
+		"pdm_steps_total{pool=\"test\"} 6\n",
+		fmt.Sprintf("pdm_clamps_total{pool=\"test\",clamp=\"cap\"} %d\n", clamps),
+		"pdm_telemetry_submissions_total{pool=\"test\"} 1\n",
+		"pdm_telemetry_rejections_total{pool=\"test\",code=\"400\"} 1\n",
+		"# TYPE pdm_step_duration_seconds histogram\n",
+		"pdm_step_duration_seconds_bucket{pool=\"test\",phase=\"step\",le=\"+Inf\"} 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestMetrics_BurnedAndTrimmedFromTraces(t *testing.T) {
+	p := testPool(t)
+	for _, entry := range pdm.StepEntries([]pdm.StepTrace{
+		{SPrev: 100, BurnAmount: 150, STemp: 0, ClampedS: true, Delta: 40, SNew: 40},
+		{SPrev: 1200, BurnAmount: 10, STemp: 1190, ClampedCap: true, Delta: -190, SNew: 1000, MCap: 1000},
+	}) {
+		if err := p.commitEntry(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var e exposition
+	p.collect(&e)
+	var b bytes.Buffer
+	e.writeTo(&b)
+	for _, want := range []string{
+		"pdm_burned_total{pool=\"test\"} 110\n",
+		"pdm_minted_total{pool=\"test\"} 40\n",
+		"pdm_cap_trimmed_total{pool=\"test\"} 190\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestMetrics_SanityRejectionsAndReload(t *testing.T) {
+	p := quarantinedPool(t)
+	if rec := postQuarantine(p, `{"action":"reject"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected reject accepted, got %d: %s", rec.Code, rec.Body)
+	}
+
+	scrape := func(p *Pool) string {
+		var e exposition
+		p.collect(&e)
+		var b bytes.Buffer
+		e.writeTo(&b)
+		return b.String()
+	}
+	want := []string{
+		"pdm_steps_total{pool=\"test\"} 1\n",
+		"pdm_steps_skipped_total{pool=\"test\",policy=\"telemetry_quarantine\"} 1\n",
+	}
+	out := scrape(p)
+	for _, w := range append(want, "pdm_telemetry_rejections_total{pool=\"test\",reason=\"sanity\"} 1\n") {
+		if !strings.Contains(out, w) {
+			t.Errorf("expected %q in:\n%s", w, out)
+		}
+	}
+
+	// The chain counters are recounted from the journal on restart.
+	reloaded := newPool(p.Spec)
+	reloaded.loadState()
+	out = scrape(reloaded)
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Errorf("after reload, expected %q in:\n%s", w, out)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/params.go
+// Scheduled parameter changes: submission API, pending store, and application at step time
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+const paramChangesFile = "param_changes.json"
+
+// PendingParamChange is a submitted, not yet applied parameter change.
+// Params overlays the parameters in force when the change is applied.
+type PendingParamChange struct {
+	ID            string    `json:"id"`
+	EffectiveDate string    `json:"effective_date"`
+	Justification string    `json:"justification"`
+	SubmittedAt   time.Time `json:"submitted_at"`
+	Params        PDMParams `json:"params"`
+}
+
+// loadPendingChanges reads the pool's param_changes.json. Changes already recorded
+// in the journal (a crash between commit and save) are dropped.
+func (p *Pool) loadPendingChanges() {
+	data, err := os.ReadFile(p.dataDir + "/" + paramChangesFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			p.log.Fatalf("Pending parameter changes read error: %v", err)
+		}
+		return
+	}
+	var pending []PendingParamChange
+	if err := json.Unmarshal(data, &pending); err != nil {
+		p.log.Fatalf("Pending parameter changes parse error: %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	applied := make(map[string]bool)
+	for _, e := range p.state.Journal {
+		if e.ParamChange != nil {
+			applied[e.ParamChange.ID] = true
+		}
+	}
+	p.pending = nil
+	for _, c := range pending {
+		if !applied[c.ID] {
+			p.pending = append(p.pending, c)
+		}
+	}
+	if len(p.pending) > 0 {
+		p.log.Printf("Loaded %d pending parameter change(s)", len(p.pending))
+	}
+}
+
+// savePendingChanges atomically writes the pending store. Callers hold p.mu.
+func (p *Pool) savePendingChanges() error {
+	data, err := json.MarshalIndent(p.pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := p.dataDir + "/" + paramChangesFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.dataDir+"/"+paramChangesFile)
+}
+
+// commitParamChange appends a sealed parameter change to the journal and
+// puts its parameters in force. Like persist, the journal append is the
+// point of no return.
+func (p *Pool) commitParamChange(pc pdm.ParamChange) error {
+	return p.commitEntry(pdm.Entry{ParamChange: &pc})
+}
+
+// applyDueParamChanges applies, in order, every pending change whose
+// effective date is on or before the schedule-timezone date of the slot at
+// `at`, stepped or skipped. Each is re-validated against the parameters
+// then in force; a change that no longer validates is dropped. A failed
+// journal append leaves the change pending for the next slot.
+func (p *Pool) applyDueParamChanges(at time.Time) {
+	date := at.In(scheduleLocation(p.Spec.Schedule)).Format("2006-01-02")
+	for {
+		p.mu.RLock()
+		if len(p.pending) == 0 || p.pending[0].EffectiveDate > date {
+			p.mu.RUnlock()
+			return
+		}
+		c := p.pending[0]
+		prevRoot := p.headRoot()
+		previous, mcap := p.state.Config, p.state.MCap
+		p.mu.RUnlock()
+
+		next := c.Params.Apply(previous)
+		if err := pdm.ValidatePDMConfig(next, mcap); err != nil {
+			p.log.Printf("ERROR: parameter change %s rejected at application: %v", c.ID, err)
+		} else {
+			pc := pdm.NewParamChange(prevRoot, c.ID, c.EffectiveDate, c.Justification, c.SubmittedAt, at, mcap, previous, next)
+			if err := p.commitParamChange(pc); err != nil {
+				p.log.Printf("ERROR: parameter change %s not applied, will retry next step: %v", c.ID, err)
+				return
+			}
+			p.log.Printf("Parameter change %s applied (effective %s)", c.ID, c.EffectiveDate)
+			for _, d := range diffPDMConfig(previous, next) {
+				p.log.Printf("  pdm.%s", d)
+			}
+		}
+
+		p.mu.Lock()
+		p.removePending(c.ID)
+		if err := p.savePendingChanges(); err != nil {
+			p.log.Printf("Pending parameter changes write error: %v", err)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// removePending deletes the pending change with the given id and reports
+// whether it existed. Callers hold p.mu.
+func (p *Pool) removePending(id string) bool {
+	for i, c := range p.pending {
+		if c.ID == id {
+			p.pending = append(p.pending[:i], p.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func newParamChangeID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("pc-%d", time.Now().UnixNano())
+	}
+	return "pc-" + hex.EncodeToString(b)
+}
+
+// paramsHandler serves /pdm/v1/params.
+//
+//	GET                 current parameters, pending and applied changes
+//	POST                submit {effective_date, justification, params}
+//	DELETE ?id=<id>     withdraw a pending change
+func (p *Pool) paramsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		p.mu.RLock()
+		applied := []pdm.ParamChange{}
+		for _, e := range p.state.Journal {
+			if e.ParamChange != nil {
+				applied = append(applied, *e.ParamChange)
+			}
+		}
+		resp := map[string]interface{}{
+			"current": p.state.Config,
+			"pending": append([]PendingParamChange{}, p.pending...),
+			"applied": applied,
+		}
+		p.mu.RUnlock()
+		writeJSON(w, http.StatusOK, resp)
+
+	case http.MethodPost:
+		body, _, ok := p.readOperatorRequest(w, r, 16*1024)
+		if !ok {
+			return
+		}
+		c, preview, err := p.decodeParamChange(body)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		p.mu.Lock()
+		p.pending = append(p.pending, c)
+		sort.SliceStable(p.pending, func(i, j int) bool {
+			return p.pending[i].EffectiveDate < p.pending[j].EffectiveDate
+		})
+		err = p.savePendingChanges()
+		if err != nil {
+			p.removePending(c.ID)
+		}
+		p.mu.Unlock()
+		if err != nil {
+			p.log.Printf("Pending parameter changes write error: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to store parameter change")
+			return
+		}
+		p.log.Printf("Parameter change %s scheduled for %s: %s", c.ID, c.EffectiveDate, c.Justification)
+		p.events.publish(eventConfig, map[string]interface{}{"action": "scheduled", "id": c.ID, "change": c})
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"change":    c,
+			"resulting": preview,
+		})
+
+	case http.MethodDelete:
+		if _, _, ok := p.readOperatorRequest(w, r, 1024); !ok {
+			return
+		}
+		id := r.URL.Query().Get("id")
+		p.mu.Lock()
+		found := p.removePending(id)
+		var err error
+		if found {
+			err = p.savePendingChanges()
+		}
+		p.mu.Unlock()
+		if !found {
+			writeJSONError(w, http.StatusNotFound, "no pending change with that id")
+			return
+		}
+		if err != nil {
+			p.log.Printf("Pending parameter changes write error: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to store parameter changes")
+			return
+		}
+		p.log.Printf("Parameter change %s withdrawn", id)
+		p.events.publish(eventConfig, map[string]interface{}{"action": "withdrawn", "id": id})
+		writeJSON(w, http.StatusOK, map[string]string{"status": "withdrawn", "id": id})
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "only GET, POST and DELETE allowed")
+	}
+}
+
+// decodeParamChange parses and validates a submission. The overlay is
+// validated against the parameters currently in force; it is validated again
+// when applied, since earlier changes may land first.
+func (p *Pool) decodeParamChange(body []byte) (PendingParamChange, pdm.PDMConfig, error) {
+	var req struct {
+		EffectiveDate string    `json:"effective_date"`
+		Justification string    `json:"justification"`
+		Params        PDMParams `json:"params"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return PendingParamChange{}, pdm.PDMConfig{}, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	loc := scheduleLocation(p.Spec.Schedule)
+	eff, err := time.ParseInLocation("2006-01-02", req.EffectiveDate, loc)
+	if err != nil {
+		return PendingParamChange{}, pdm.PDMConfig{}, errors.New("effective_date must be YYYY-MM-DD")
+	}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if eff.Before(today) {
+		return PendingParamChange{}, pdm.PDMConfig{}, errors.New("effective_date is in the past")
+	}
+	if strings.TrimSpace(req.Justification) == "" {
+		return PendingParamChange{}, pdm.PDMConfig{}, errors.New("justification is required")
+	}
+	if req.Params == (PDMParams{}) {
+		return PendingParamChange{}, pdm.PDMConfig{}, errors.New("params must set at least one parameter")
+	}
+
+	p.mu.RLock()
+	preview := req.Params.Apply(p.state.Config)
+	mcap := p.state.MCap
+	p.mu.RUnlock()
+	if err := pdm.ValidatePDMConfig(preview, mcap); err != nil {
+		return PendingParamChange{}, pdm.PDMConfig{}, err
+	}
+
+	return PendingParamChange{
+		ID:            newParamChangeID(),
+		EffectiveDate: req.EffectiveDate,
+		Justification: strings.TrimSpace(req.Justification),
+		SubmittedAt:   time.Now().UTC(),
+		Params:        req.Params,
+	}, preview, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+func TestParamChange_ScheduledThenChained(t *testing.T) {
+	p := testPool(t)
+	mcap := 1000000.0
+	cfg := pdm.DefaultConfig(mcap)
+	g := pdm.NewGenesis("test", "units", mcap, 618000, cfg, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	p.state = PoolState{S: 618000, MCap: mcap, Config: cfg, GenesisRoot: g.HashChainRoot}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/pdm/v1/params", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer operator-secret")
+		p.paramsHandler(rec, req)
+		return rec
+	}
+	if rec := post(`{"effective_date":"2099-01-01","justification":"x","params":{"band_low":0.5}}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("no credential configured: expected 403, got %d", rec.Code)
+	}
+	p.Spec.Operator.AuthToken = "operator-secret"
+	if rec := post(`{"effective_date":"2020-01-01","justification":"x","params":{"band_low":0.5}}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("past effective date: expected 400, got %d", rec.Code)
+	}
+	if rec := post(`{"effective_date":"2099-01-01","justification":"x","params":{"band_low":0.9}}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid band: expected 400, got %d", rec.Code)
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	if rec := post(`{"effective_date":"` + today + `","justification":"widen band","params":{"band_low":0.5}}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Not yet due for a step dated yesterday; due for one dated tomorrow.
+	p.applyDueParamChanges(time.Now().UTC().AddDate(0, 0, -1))
+	if len(p.state.Journal) != 0 {
+		t.Fatalf("change applied before its effective date")
+	}
+	at := time.Now().UTC().AddDate(0, 0, 1)
+	p.applyDueParamChanges(at)
+	if len(p.pending) != 0 || len(p.state.Journal) != 1 || p.state.Config.BandLow != 0.5 {
+		t.Fatalf("expected change applied, got pending=%d journal=%d band_low=%g", len(p.pending), len(p.state.Journal), p.state.Config.BandLow)
+	}
+
+	_, tr := pdm.StepPDMAt(at, p.state.S, 1000000, 50000, mcap, p.headRoot(), p.state.Config)
+	if err := p.persist(tr); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := readJournal(p.dataDir + "/" + journalFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep := pdm.AuditEntries(entries, g.HashChainRoot, &cfg); !rep.Valid {
+		t.Fatalf("journal with parameter change failed audit: %+v", rep)
+	}
+
+	rec := httptest.NewRecorder()
+	p.paramsHandler(rec, httptest.NewRequest(http.MethodGet, "/pdm/v1/params", nil))
+	var resp struct {
+		Applied []pdm.ParamChange `json:"applied"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Applied) != 1 {
+		t.Fatalf("expected one applied change listed, got %s", rec.Body.String())
+	}
+}
+
+func TestParamChange_TelemetryTokenForbidden(t *testing.T) {
+	p := testPoolWithGenesis(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	p.Spec.Telemetry.AuthToken = "submitter-secret"
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-PDM-Token", "submitter-secret")
+		rec := httptest.NewRecorder()
+		p.paramsHandler(rec, req)
+		return rec
+	}
+	change := `{"effective_date":"2099-01-01","justification":"x","params":{"band_low":0.5}}`
+
+	// Refused whether or not an operator credential is configured.
+	for _, operator := range []string{"", "operator-secret"} {
+		p.Spec.Operator.AuthToken = operator
+		if rec := send(http.MethodPost, "/pdm/v1/params", change); rec.Code != http.StatusForbidden {
+			t.Fatalf("POST with telemetry token (operator %q): expected 403, got %d", operator, rec.Code)
+		}
+		if rec := send(http.MethodDelete, "/pdm/v1/params?id=x", ""); rec.Code != http.StatusForbidden {
+			t.Fatalf("DELETE with telemetry token (operator %q): expected 403, got %d", operator, rec.Code)
+		}
+	}
+	if len(p.pending) != 0 {
+		t.Fatalf("a telemetry submitter scheduled a change: %+v", p.pending)
+	}
+}
+
+func TestParamChange_DueBySlotDateInScheduleZone(t *testing.T) {
+	p := testPoolWithGenesis(t, time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC))
+	p.Spec.Schedule = ScheduleConfig{RunTime: "01:00", Timezone: "Asia/Tokyo"}
+	band := func(v float64) *float64 { return &v }
+	p.pending = []PendingParamChange{
+		{ID: "pc-1", EffectiveDate: "2026-03-02", Justification: "x", Params: PDMParams{BandLow: band(0.55)}},
+		{ID: "pc-2", EffectiveDate: "2026-03-03", Justification: "y", Params: PDMParams{BandLow: band(0.5)}},
+	}
+
+	// 01:00 in Tokyo on Mar 2 is still Mar 1 in UTC.
+	p.skip(time.Date(2026, 3, 1, 16, 0, 0, 0, time.UTC), pdm.SkipPolicySkip, "test")
+	j := p.state.Journal
+	if len(j) != 2 || j[0].ParamChange == nil || j[0].ParamChange.ID != "pc-1" || j[1].Skipped == nil {
+		t.Fatalf("expected pc-1 applied at the skipped Mar 2 slot, got %d entries", len(j))
+	}
+	if len(p.pending) != 1 || p.state.Config.BandLow != 0.55 {
+		t.Fatalf("expected pc-2 still pending and band_low 0.55, got %d pending, %g", len(p.pending), p.state.Config.BandLow)
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, j); !rep.Valid {
+		t.Fatalf("change before a skip failed audit: %+v", rep)
+	}
+}
@@ -0,0 +1,240 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/pdm/audit.go
+// Full offline audit: hash chain, control-law replay and theorem invariants
+
+package pdm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Bundle is a self-contained export of a pool's trace history that can be
+// audited without access to the server.
+type Bundle struct {
+	Version    string    `json:"pdm_version"`
+	PoolName   string    `json:"pool_name,omitempty"`
+	MCap       float64   `json:"m_cap"`
+	Config     PDMConfig `json:"config"`
+	Genesis    *Genesis  `json:"genesis,omitempty"`
+	AnchorRoot string    `json:"anchor_root"`
+	ExportedAt time.Time `json:"exported_at"`
+	Traces     []Entry   `json:"traces"`
+}
+
+// ReplayMismatch is a trace field whose recorded value differs from the
+// value StepPDMAt produces for the same inputs.
+type ReplayMismatch struct {
+	Index     int       `json:"index"`
+	Timestamp time.Time `json:"timestamp"`
+	Field     string    `json:"field"`
+	Recorded  string    `json:"recorded"`
+	Replayed  string    `json:"replayed"`
+}
+
+// Violation is a breach of a whitepaper guarantee or of step continuity.
+type Violation struct {
+	Index     int       `json:"index"`
+	Timestamp time.Time `json:"timestamp"`
+	Rule      string    `json:"rule"`
+	Detail    string    `json:"detail"`
+}
+
+// AuditReport is the result of Audit.
+type AuditReport struct {
+	Valid            bool             `json:"valid"`
+	Genesis          *Genesis         `json:"genesis,omitempty"`
+	Chain            ChainReport      `json:"chain"`
+	StepsReplayed    int              `json:"steps_replayed"`
+	ReplayMismatches []ReplayMismatch `json:"replay_mismatches,omitempty"`
+	Violations       []Violation      `json:"violations,omitempty"`
+}
+
+// Audit verifies the hash chain from anchorRoot, replays every step through
+// StepPDMAt and checks the theorem invariants. The control-law parameters
+// are taken from each trace; cfg supplies MinS and MinO, which traces do not
+// record. When cfg is nil the DefaultConfig floors for each trace's MCap are
+// used.
+func Audit(traces []StepTrace, anchorRoot string, cfg *PDMConfig) AuditReport {
+	return AuditEntries(StepEntries(traces), anchorRoot, cfg)
+}
+
+// AuditEntries audits a chain of steps and parameter changes. initial is
+// the configuration in force at anchorRoot; when it is known, every step is
+// also checked against the parameters governing it and every parameter
+// change against the configuration it claims to replace. Parameter changes
+// update the governing configuration, including MinS and MinO used for
+// replay.
+func AuditEntries(entries []Entry, anchorRoot string, initial *PDMConfig) AuditReport {
+	report := AuditReport{Chain: VerifyEntries(entries, anchorRoot)}
+
+	var governing *PDMConfig
+	if initial != nil {
+		cfg := *initial
+		governing = &cfg
+	}
+	violate := func(i int, ts time.Time, rule, format string, args ...interface{}) {
+		report.Violations = append(report.Violations, Violation{Index: i, Timestamp: ts, Rule: rule, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	prev := anchorRoot
+	var last *StepTrace
+	for i, e := range entries {
+		if pc := e.ParamChange; pc != nil {
+			if governing != nil && pc.Previous != *governing {
+				violate(i, pc.AppliedAt, "param_change_previous", "change %s replaces parameters that were not in force", pc.ID)
+			}
+			if err := ValidatePDMConfig(pc.New, pc.MCap); err != nil {
+				violate(i, pc.AppliedAt, "param_change_invalid", "change %s: %v", pc.ID, err)
+			}
+			cfg := pc.New
+			governing = &cfg
+			prev = pc.HashChainRoot
+			continue
+		}
+		if sk := e.Skipped; sk != nil {
+			if last != nil && !approxEqual(last.SupplyAfter(), sk.S) {
+				violate(i, sk.ScheduledAt, "continuity", "skipped step carries s %f but previous supply is %f", sk.S, last.SupplyAfter())
+			}
+			prev = sk.HashChainRoot
+			continue
+		}
+
+		tr := *e.Step
+		if tr.Error == "" {
+			report.StepsReplayed++
+			report.ReplayMismatches = append(report.ReplayMismatches, compareReplay(i, tr, ReplayStep(tr, prev, governing))...)
+		}
+		if governing != nil && !governs(*governing, tr) {
+			violate(i, tr.Timestamp, "governing_config", "step parameters differ from the configuration in force")
+		}
+		report.Violations = append(report.Violations, CheckInvariants(i, tr)...)
+		if last != nil && !approxEqual(last.SupplyAfter(), tr.SPrev) {
+			violate(i, tr.Timestamp, "continuity", "s_prev %f does not match previous supply %f", tr.SPrev, last.SupplyAfter())
+		}
+		last = &tr
+		prev = tr.HashChainRoot
+	}
+
+	report.Valid = report.Chain.Valid && len(report.ReplayMismatches) == 0 && len(report.Violations) == 0
+	return report
+}
+
+// governs reports whether the traced parameters match cfg.
+func governs(cfg PDMConfig, tr StepTrace) bool {
+	return tr.PhiTarget == cfg.PhiTarget && tr.BandLow == cfg.BandLow && tr.BandHigh == cfg.BandHigh &&
+		tr.BurnBase == cfg.BurnBase && tr.BurnVelocityK == cfg.BurnVelocityK
+}
+
+// ReplayStep recomputes tr from its recorded inputs, chaining from prevRoot.
+func ReplayStep(tr StepTrace, prevRoot string, cfg *PDMConfig) StepTrace {
+	stepCfg := DefaultConfig(tr.MCap)
+	if cfg != nil {
+		stepCfg.MinS = cfg.MinS
+		stepCfg.MinO = cfg.MinO
+	}
+	stepCfg.PhiTarget = tr.PhiTarget
+	stepCfg.BandLow = tr.BandLow
+	stepCfg.BandHigh = tr.BandHigh
+	stepCfg.BurnBase = tr.BurnBase
+	stepCfg.BurnVelocityK = tr.BurnVelocityK
+
+	oi, v := tr.Oi, tr.VTotal
+	if tr.Telemetry != nil && tr.Telemetry.Provenance != nil {
+		// Replay from the inputs as supplied, so the MinO floor is
+		// checked too.
+		oi, v = tr.Telemetry.Provenance.OiRaw, tr.Telemetry.Provenance.VRaw
+	}
+	_, replayed := StepPDMAt(tr.Timestamp, tr.SPrev, oi, v, tr.MCap, prevRoot, stepCfg)
+	if tr.Telemetry != nil {
+		replayed.Annotate(prevRoot, tr.Telemetry)
+	}
+	return replayed
+}
+
+// CheckInvariants checks trace i against Theorems 1, 2 and 4: 0 <= S <= M,
+// and minting only when L < b_L.
+func CheckInvariants(i int, tr StepTrace) []Violation {
+	if tr.Error != "" {
+		return nil
+	}
+	var out []Violation
+	add := func(rule, format string, args ...interface{}) {
+		out = append(out, Violation{Index: i, Timestamp: tr.Timestamp, Rule: rule, Detail: fmt.Sprintf(format, args...)})
+	}
+	if tr.SNew < 0 || tr.STemp < 0 {
+		add("theorem1_non_negativity", "supply went negative (s_temp=%f, s_new=%f)", tr.STemp, tr.SNew)
+	}
+	if tr.SNew > tr.MCap {
+		add("theorem2_capacity", "s_new %f exceeds m_cap %f", tr.SNew, tr.MCap)
+	}
+	if tr.Delta > 0 && tr.L >= tr.BandLow {
+		add("theorem4_conditional_mint", "minted %f with L=%f >= band_low %f", tr.Delta, tr.L, tr.BandLow)
+	}
+	if tr.Delta < 0 {
+		add("theorem4_conditional_mint", "negative mint delta %f", tr.Delta)
+	}
+	return out
+}
+
+// compareReplay lists the JSON fields that differ between the recorded and
+// replayed trace.
+func compareReplay(i int, recorded, replayed StepTrace) []ReplayMismatch {
+	a, b := traceFields(recorded), traceFields(replayed)
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var out []ReplayMismatch
+	for _, k := range keys {
+		if reflect.DeepEqual(a[k], b[k]) {
+			continue
+		}
+		out = append(out, ReplayMismatch{
+			Index:     i,
+			Timestamp: recorded.Timestamp,
+			Field:     k,
+			Recorded:  fmt.Sprint(a[k]),
+			Replayed:  fmt.Sprint(b[k]),
+		})
+	}
+	return out
+}
+
+func traceFields(tr StepTrace) map[string]interface{} {
+	var m map[string]interface{}
+	b, _ := json.Marshal(tr)
+	_ = json.Unmarshal(b, &m)
+	return m
+}
@@ -0,0 +1,75 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/pdm/config.go
+// Control-law parameters and coherence validation (Section 3 of whitepaper)
+
+package pdm
+
+import "fmt"
+
+type PDMConfig struct {
+	PhiTarget     float64 `json:"phi_target"`
+	BandLow       float64 `json:"band_low"`
+	BandHigh      float64 `json:"band_high"`
+	BurnBase      float64 `json:"burn_base"`
+	BurnVelocityK float64 `json:"burn_velocity_k"`
+	MinS          float64 `json:"min_s"`
+	MinO          float64 `json:"min_o"`
+}
+
+func DefaultConfig(mcap float64) PDMConfig {
+	return PDMConfig{
+		PhiTarget:     0.618,
+		BandLow:       0.60,
+		BandHigh:      0.62,
+		BurnBase:      0.000618,
+		BurnVelocityK: 0.1,
+		MinS:          1e-9 * mcap,
+		MinO:          1e-6,
+	}
+}
+
+func ValidatePDMConfig(cfg PDMConfig, mcap float64) error {
+	if cfg.PhiTarget <= 0 || cfg.PhiTarget >= 1 {
+		return fmt.Errorf("phi_target must be in (0, 1), got %f", cfg.PhiTarget)
+	}
+	if cfg.BandLow <= 0 || cfg.BandHigh <= 0 {
+		return fmt.Errorf("band_low and band_high must be > 0")
+	}
+	if cfg.BandLow >= cfg.BandHigh {
+		return fmt.Errorf("band_low must be < band_high, got %f >= %f", cfg.BandLow, cfg.BandHigh)
+	}
+	if cfg.BandLow > cfg.PhiTarget || cfg.PhiTarget > cfg.BandHigh {
+		return fmt.Errorf("band_low <= phi_target <= band_high required, got %f <= %f <= %f violated", cfg.BandLow, cfg.PhiTarget, cfg.BandHigh)
+	}
+	if mcap <= 0 {
+		return fmt.Errorf("mcap must be > 0")
+	}
+	if cfg.MinS <= 0 {
+		return fmt.Errorf("min_s must be > 0")
+	}
+	if cfg.MinO <= 0 {
+		return fmt.Errorf("min_o must be > 0")
+	}
+	return nil
+}
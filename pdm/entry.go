@@ -0,0 +1,249 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/pdm/entry.go
+// Chain entries: step traces and parameter-change records sharing one hash chain
+
+package pdm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// RecordParamChange is the record discriminator of a ParamChange. Step
+// traces carry no discriminator, so journals written before parameter
+// governance existed still decode unchanged.
+const RecordParamChange = "param_change"
+
+// RecordSkippedStep is the record discriminator of a SkippedStep.
+const RecordSkippedStep = "skipped_step"
+
+// Policies recorded in SkippedStep.Policy: the schedule.catch_up policies
+// for missed slots, and the telemetry.on_failure policies for steps whose
+// telemetry could not be obtained.
+const (
+	SkipPolicySkip     = "skip"
+	SkipPolicyCombined = "combined"
+	SkipPolicyReplay   = "replay"
+
+	SkipPolicyTelemetrySkip  = "telemetry_skip"
+	SkipPolicyTelemetryAbort = "telemetry_abort"
+
+	// SkipPolicyQuarantine records a step whose telemetry failed the
+	// sanity gate and was rejected or never confirmed by an operator.
+	SkipPolicyQuarantine = "telemetry_quarantine"
+)
+
+// ParamChange records a change of the control-law parameters. It is chained
+// between steps like a trace, so the parameters that governed every step can
+// be proven from the chain alone.
+type ParamChange struct {
+	Record        string    `json:"record"`
+	ID            string    `json:"id"`
+	EffectiveDate string    `json:"effective_date"`
+	Justification string    `json:"justification"`
+	SubmittedAt   time.Time `json:"submitted_at"`
+	AppliedAt     time.Time `json:"applied_at"`
+	MCap          float64   `json:"m_cap"`
+	Previous      PDMConfig `json:"previous"`
+	New           PDMConfig `json:"new"`
+	HashChainRoot string    `json:"hash_chain_root"`
+}
+
+// NewParamChange builds and seals a parameter change chained from prevRoot.
+func NewParamChange(prevRoot, id, effectiveDate, justification string, submittedAt, appliedAt time.Time, mcap float64, previous, next PDMConfig) ParamChange {
+	pc := ParamChange{
+		Record:        RecordParamChange,
+		ID:            id,
+		EffectiveDate: effectiveDate,
+		Justification: justification,
+		SubmittedAt:   submittedAt.UTC(),
+		AppliedAt:     appliedAt.UTC(),
+		MCap:          mcap,
+		Previous:      previous,
+		New:           next,
+	}
+	pc.HashChainRoot = HashParamChange(prevRoot, pc)
+	return pc
+}
+
+// HashParamChange computes the chain root of pc exactly as HashTrace does
+// for steps: SHA-256 over prevRoot and the JSON with HashChainRoot cleared.
+func HashParamChange(prevRoot string, pc ParamChange) string {
+	pc.HashChainRoot = ""
+	return hashRecord(prevRoot, pc)
+}
+
+// SkippedStep records a scheduled step that did not run, such as one missed
+// while the server was down. Supply is unchanged: S is the supply carried
+// across the slot, so the chain shows every scheduled date either stepped or
+// explicitly skipped.
+type SkippedStep struct {
+	Record        string    `json:"record"`
+	ScheduledAt   time.Time `json:"scheduled_at"`
+	Policy        string    `json:"policy"`
+	Reason        string    `json:"reason"`
+	S             float64   `json:"s"`
+	MCap          float64   `json:"m_cap"`
+	HashChainRoot string    `json:"hash_chain_root"`
+}
+
+// NewSkippedStep builds and seals a skipped-step record chained from prevRoot.
+func NewSkippedStep(prevRoot string, scheduledAt time.Time, policy, reason string, s, mcap float64) SkippedStep {
+	sk := SkippedStep{
+		Record:      RecordSkippedStep,
+		ScheduledAt: scheduledAt.UTC(),
+		Policy:      policy,
+		Reason:      reason,
+		S:           s,
+		MCap:        mcap,
+	}
+	sk.HashChainRoot = HashSkippedStep(prevRoot, sk)
+	return sk
+}
+
+// HashSkippedStep computes the chain root of sk as HashTrace does for steps.
+func HashSkippedStep(prevRoot string, sk SkippedStep) string {
+	sk.HashChainRoot = ""
+	return hashRecord(prevRoot, sk)
+}
+
+// Entry is one record of a pool's chain: exactly one of Step, ParamChange
+// or Skipped is set. It marshals as the bare record, so a journal line or
+// bundle item is a StepTrace, a ParamChange or a SkippedStep.
+type Entry struct {
+	Step        *StepTrace
+	ParamChange *ParamChange
+	Skipped     *SkippedStep
+}
+
+// StepEntries wraps a trace slice as chain entries.
+func StepEntries(traces []StepTrace) []Entry {
+	out := make([]Entry, len(traces))
+	for i := range traces {
+		tr := traces[i]
+		out[i] = Entry{Step: &tr}
+	}
+	return out
+}
+
+// Steps returns the step traces in entries, in chain order.
+func Steps(entries []Entry) []StepTrace {
+	var out []StepTrace
+	for _, e := range entries {
+		if e.Step != nil {
+			out = append(out, *e.Step)
+		}
+	}
+	return out
+}
+
+// Root returns the entry's HashChainRoot.
+func (e Entry) Root() string {
+	if e.ParamChange != nil {
+		return e.ParamChange.HashChainRoot
+	}
+	if e.Skipped != nil {
+		return e.Skipped.HashChainRoot
+	}
+	if e.Step != nil {
+		return e.Step.HashChainRoot
+	}
+	return ""
+}
+
+// Time returns the step timestamp, the time a parameter change applied, or
+// the scheduled time of a skipped step.
+func (e Entry) Time() time.Time {
+	if e.ParamChange != nil {
+		return e.ParamChange.AppliedAt
+	}
+	if e.Skipped != nil {
+		return e.Skipped.ScheduledAt
+	}
+	if e.Step != nil {
+		return e.Step.Timestamp
+	}
+	return time.Time{}
+}
+
+// Hash recomputes the entry's root from prevRoot.
+func (e Entry) Hash(prevRoot string) string {
+	if e.ParamChange != nil {
+		return HashParamChange(prevRoot, *e.ParamChange)
+	}
+	if e.Skipped != nil {
+		return HashSkippedStep(prevRoot, *e.Skipped)
+	}
+	if e.Step != nil {
+		return HashTrace(prevRoot, *e.Step)
+	}
+	return ""
+}
+
+func (e Entry) MarshalJSON() ([]byte, error) {
+	if e.ParamChange != nil {
+		return json.Marshal(e.ParamChange)
+	}
+	if e.Skipped != nil {
+		return json.Marshal(e.Skipped)
+	}
+	if e.Step != nil {
+		return json.Marshal(e.Step)
+	}
+	return nil, errors.New("pdm: empty chain entry")
+}
+
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var probe struct {
+		Record string `json:"record"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+	switch probe.Record {
+	case "":
+		var tr StepTrace
+		if err := json.Unmarshal(data, &tr); err != nil {
+			return err
+		}
+		*e = Entry{Step: &tr}
+	case RecordParamChange:
+		var pc ParamChange
+		if err := json.Unmarshal(data, &pc); err != nil {
+			return err
+		}
+		*e = Entry{ParamChange: &pc}
+	case RecordSkippedStep:
+		var sk SkippedStep
+		if err := json.Unmarshal(data, &sk); err != nil {
+			return err
+		}
+		*e = Entry{Skipped: &sk}
+	default:
+		return errors.New("pdm: unknown chain record " + string(bytes.TrimSpace([]byte(probe.Record))))
+	}
+	return nil
+}
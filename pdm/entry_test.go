@@ -0,0 +1,103 @@
+package pdm
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// chainWithChange builds two steps, a band change, and two steps under the
+// new band.
+func chainWithChange(t *testing.T) ([]Entry, PDMConfig) {
+	t.Helper()
+	mcap := 1000000.0
+	cfg := DefaultConfig(mcap)
+	wide := cfg
+	wide.BandLow, wide.BandHigh = 0.5, 0.7
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var entries []Entry
+	s, prev := 400000.0, ""
+	step := func(i int, c PDMConfig) {
+		var tr StepTrace
+		s, tr = StepPDMAt(at.AddDate(0, 0, i), s, 1000000, 80000, mcap, prev, c)
+		prev = tr.HashChainRoot
+		entries = append(entries, Entry{Step: &tr})
+	}
+	step(0, cfg)
+	step(1, cfg)
+	pc := NewParamChange(prev, "pc-1", "2026-01-03", "widen band", at, at.AddDate(0, 0, 2), mcap, cfg, wide)
+	prev = pc.HashChainRoot
+	entries = append(entries, Entry{ParamChange: &pc})
+	step(2, wide)
+	step(3, wide)
+	return entries, cfg
+}
+
+func TestAuditEntries_ParamChangeGovernsLaterSteps(t *testing.T) {
+	entries, cfg := chainWithChange(t)
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Entry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded[2].ParamChange == nil || decoded[3].Step == nil {
+		t.Fatalf("entries did not round-trip by record type")
+	}
+
+	rep := AuditEntries(decoded, "", &cfg)
+	if !rep.Valid || rep.Chain.ParamChanges != 1 || rep.Chain.Steps != 4 || rep.Chain.Entries != 5 {
+		t.Fatalf("expected a valid audit across the change, got %+v", rep)
+	}
+}
+
+func TestAuditEntries_DetectsUnrecordedParamChange(t *testing.T) {
+	entries, cfg := chainWithChange(t)
+
+	// Drop the change record and re-link the chain around it: the later
+	// steps were then run under parameters nothing on the chain put in force.
+	var relinked []Entry
+	prev := ""
+	for _, e := range entries {
+		if e.ParamChange != nil {
+			continue
+		}
+		tr := *e.Step
+		tr.HashChainRoot = HashTrace(prev, tr)
+		prev = tr.HashChainRoot
+		relinked = append(relinked, Entry{Step: &tr})
+	}
+
+	rep := AuditEntries(relinked, "", &cfg)
+	if !rep.Chain.Valid {
+		t.Fatalf("relinked chain should hash-verify: %+v", rep.Chain)
+	}
+	found := false
+	for _, v := range rep.Violations {
+		found = found || v.Rule == "governing_config"
+	}
+	if rep.Valid || !found {
+		t.Fatalf("expected a governing_config violation, got %+v", rep.Violations)
+	}
+}
+
+func TestAuditEntries_SkippedStepMustCarrySupply(t *testing.T) {
+	entries, cfg := chainWithChange(t)
+	last := entries[len(entries)-1]
+	at := last.Time().AddDate(0, 0, 1)
+
+	ok := NewSkippedStep(last.Root(), at, SkipPolicySkip, "down", last.Step.SNew, last.Step.MCap)
+	if rep := AuditEntries(append(entries, Entry{Skipped: &ok}), "", &cfg); !rep.Valid || rep.Chain.Skipped != 1 {
+		t.Fatalf("expected valid audit with one skip, got %+v", rep)
+	}
+
+	bad := NewSkippedStep(last.Root(), at, SkipPolicySkip, "down", last.Step.SNew+1, last.Step.MCap)
+	rep := AuditEntries(append(entries, Entry{Skipped: &bad}), "", &cfg)
+	if rep.Valid || len(rep.Violations) != 1 || rep.Violations[0].Rule != "continuity" {
+		t.Fatalf("expected a continuity violation, got %+v", rep.Violations)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/pdm/genesis.go
+// Genesis record: anchors a pool's hash chain to its bootstrap configuration
+
+package pdm
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Genesis captures the configuration and initial state a pool was
+// bootstrapped with. Its HashChainRoot is the root the first step chains
+// from, so two pools fed identical telemetry still produce distinct chains
+// and a verifier can prove where a chain began.
+type Genesis struct {
+	PoolName      string    `json:"pool_name"`
+	Unit          string    `json:"unit,omitempty"`
+	MCap          float64   `json:"m_cap"`
+	InitialS      float64   `json:"initial_s"`
+	Config        PDMConfig `json:"config"`
+	CreatedAt     time.Time `json:"created_at"`
+	PDMVersion    string    `json:"pdm_version"`
+	HashChainRoot string    `json:"hash_chain_root"`
+}
+
+// NewGenesis builds and seals a genesis record.
+func NewGenesis(poolName, unit string, mcap, initialS float64, cfg PDMConfig, createdAt time.Time) Genesis {
+	g := Genesis{
+		PoolName:   poolName,
+		Unit:       unit,
+		MCap:       mcap,
+		InitialS:   initialS,
+		Config:     cfg,
+		CreatedAt:  createdAt.UTC(),
+		PDMVersion: Version,
+	}
+	g.HashChainRoot = HashGenesis(g)
+	return g
+}
+
+// HashGenesis computes the genesis root: SHA-256 over the record JSON with
+// HashChainRoot cleared. There is no previous root.
+func HashGenesis(g Genesis) string {
+	g.HashChainRoot = ""
+	genesisJSON, _ := json.Marshal(g)
+	return fmt.Sprintf("%x", sha256.Sum256(genesisJSON))
+}
+
+// CheckGenesis verifies the genesis seal and that the chain starts from the
+// recorded initial state and parameters.
+func CheckGenesis(g Genesis, entries []Entry) []Violation {
+	var out []Violation
+	add := func(i int, ts time.Time, rule, format string, args ...interface{}) {
+		out = append(out, Violation{Index: i, Timestamp: ts, Rule: rule, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	if expected := HashGenesis(g); expected != g.HashChainRoot {
+		add(-1, g.CreatedAt, "genesis_seal", "genesis root %s does not match recomputed %s", g.HashChainRoot, expected)
+	}
+	if len(entries) == 0 {
+		return out
+	}
+
+	if first := entries[0]; first.Time().Before(g.CreatedAt) {
+		add(0, first.Time(), "genesis_order", "first entry at %s precedes genesis at %s",
+			first.Time().Format(time.RFC3339), g.CreatedAt.Format(time.RFC3339))
+	}
+	// Parameters are checked by AuditEntries against the genesis config;
+	// here only the first step's starting supply and capacity, and the
+	// supply carried by any skipped steps before it.
+	for i, e := range entries {
+		if sk := e.Skipped; sk != nil {
+			if sk.S != g.InitialS {
+				add(i, sk.ScheduledAt, "genesis_initial_s", "skipped step s %f differs from genesis initial_s %f", sk.S, g.InitialS)
+			}
+			continue
+		}
+		if e.Step == nil {
+			continue
+		}
+		first := *e.Step
+		if first.SPrev != g.InitialS {
+			add(i, first.Timestamp, "genesis_initial_s", "first step s_prev %f differs from genesis initial_s %f", first.SPrev, g.InitialS)
+		}
+		if first.MCap != g.MCap {
+			add(i, first.Timestamp, "genesis_m_cap", "first step m_cap %f differs from genesis m_cap %f", first.MCap, g.MCap)
+		}
+		break
+	}
+	return out
+}
+
+// AuditFromGenesis runs AuditEntries anchored at g, with the genesis config
+// as the initial governing configuration, and adds the genesis checks.
+func AuditFromGenesis(g Genesis, entries []Entry) AuditReport {
+	cfg := g.Config
+	report := AuditEntries(entries, g.HashChainRoot, &cfg)
+	report.Genesis = &g
+	report.Violations = append(CheckGenesis(g, entries), report.Violations...)
+	report.Valid = report.Chain.Valid && len(report.ReplayMismatches) == 0 && len(report.Violations) == 0
+	return report
+}
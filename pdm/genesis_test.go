@@ -0,0 +1,50 @@
+package pdm
+
+import (
+	"testing"
+	"time"
+)
+
+// chainFromGenesis steps n days from g, which must be a default 1,000,000
+// cap pool sealed no later than 2026-01-01.
+func chainFromGenesis(g Genesis, n int) []StepTrace {
+	return buildChain(n, g.HashChainRoot, g.InitialS)
+}
+
+func TestGenesis_DistinctPoolsDistinctChains(t *testing.T) {
+	mcap := 1000000.0
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := NewGenesis("compute", "credits", mcap, 618000, DefaultConfig(mcap), created)
+	b := NewGenesis("storage", "credits", mcap, 618000, DefaultConfig(mcap), created)
+	if a.HashChainRoot == b.HashChainRoot {
+		t.Fatalf("pools with different names share genesis root %s", a.HashChainRoot)
+	}
+
+	ta, tb := chainFromGenesis(a, 3), chainFromGenesis(b, 3)
+	if ta[2].HashChainRoot == tb[2].HashChainRoot {
+		t.Fatalf("identical telemetry produced identical chains across pools")
+	}
+	if rep := AuditFromGenesis(a, StepEntries(ta)); !rep.Valid {
+		t.Fatalf("expected valid audit from genesis, got %+v", rep)
+	}
+	// Chain a does not start from genesis b.
+	if rep := AuditFromGenesis(b, StepEntries(ta)); rep.Valid || rep.Chain.FirstBreak == nil || rep.Chain.FirstBreak.Index != 0 {
+		t.Fatalf("expected break at first step against foreign genesis, got %+v", rep.Chain)
+	}
+}
+
+func TestCheckGenesis_DetectsTamperedSeal(t *testing.T) {
+	mcap := 1000000.0
+	g := NewGenesis("compute", "credits", mcap, 618000, DefaultConfig(mcap), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	traces := chainFromGenesis(g, 2)
+
+	g.InitialS = 700000
+	v := CheckGenesis(g, StepEntries(traces))
+	rules := map[string]bool{}
+	for _, x := range v {
+		rules[x.Rule] = true
+	}
+	if !rules["genesis_seal"] || !rules["genesis_initial_s"] {
+		t.Fatalf("expected genesis_seal and genesis_initial_s violations, got %+v", v)
+	}
+}
@@ -0,0 +1,35 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/pdm/pdm.go
+// Importable PDM core shared by the server, the harness and third-party programs
+
+// Package pdm implements the Progressive Depletion Minting control law.
+//
+// The server in the repository root, the simulation harness and any
+// third-party program import this package so that there is exactly one
+// implementation of StepPDM. Core PDM logic is patent-locked; changes to
+// the step semantics are reflected in Version.
+package pdm
+
+// Version identifies the revision of the PDM core implemented by this package.
+const Version = "1.0.1"
@@ -0,0 +1,138 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/pdm/step.go
+// StepPDM: the PDM state transition (burn, conditional mint, progressive damping)
+
+package pdm
+
+import (
+	"math"
+	"time"
+)
+
+// Clock supplies the step time for StepPDMWithClock.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock reads the wall clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// FixedClock always returns the same instant; used for replays and audits.
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time { return time.Time(c) }
+
+// StepPDM runs one step stamped with the current wall-clock time.
+// Hash chains produced this way cannot be reproduced from telemetry alone;
+// use StepPDMAt when the step time is known (scheduled runs, replays, audits).
+func StepPDM(sPrev, oi, vtotal, mcap float64, prevHashChainRoot string, cfg PDMConfig) (float64, StepTrace) {
+	return StepPDMAt(time.Now(), sPrev, oi, vtotal, mcap, prevHashChainRoot, cfg)
+}
+
+// StepPDMWithClock runs one step stamped with clock.Now().
+func StepPDMWithClock(clock Clock, sPrev, oi, vtotal, mcap float64, prevHashChainRoot string, cfg PDMConfig) (float64, StepTrace) {
+	return StepPDMAt(clock.Now(), sPrev, oi, vtotal, mcap, prevHashChainRoot, cfg)
+}
+
+// StepPDMAt runs one step stamped with the given time. The result is a pure
+// function of its arguments: identical inputs always yield a byte-identical
+// trace and HashChainRoot. The timestamp is normalised to UTC and any
+// monotonic clock reading is dropped so it survives a JSON round trip.
+func StepPDMAt(at time.Time, sPrev, oi, vtotal, mcap float64, prevHashChainRoot string, cfg PDMConfig) (float64, StepTrace) {
+	trace := StepTrace{
+		Timestamp:     at.UTC(),
+		SPrev:         sPrev,
+		Oi:            oi,
+		VTotal:        vtotal,
+		MCap:          mcap,
+		PhiTarget:     cfg.PhiTarget,
+		BandLow:       cfg.BandLow,
+		BandHigh:      cfg.BandHigh,
+		BurnBase:      cfg.BurnBase,
+		BurnVelocityK: cfg.BurnVelocityK,
+	}
+
+	if mcap <= 0 {
+		trace.Error = "M_cap must be > 0"
+		return sPrev, trace
+	}
+	if oi < cfg.MinO {
+		oi = cfg.MinO
+		trace.Oi = oi
+	}
+	sSafe := math.Max(sPrev, cfg.MinS)
+
+	velocity := vtotal / sSafe
+	deviation := velocity - cfg.PhiTarget
+	burnRate := 1.0 - cfg.BurnVelocityK*deviation
+	if burnRate < 0 {
+		burnRate = 0
+	}
+	burnAmount := cfg.BurnBase * burnRate * vtotal
+	sTemp := sPrev - burnAmount
+
+	if sTemp < 0 {
+		trace.ClampedS = true
+		sTemp = 0
+	}
+
+	trace.Velocity = velocity
+	trace.BurnRate = burnRate
+	trace.BurnAmount = burnAmount
+	trace.STemp = sTemp
+
+	l := sTemp / oi
+	trace.L = l
+
+	var delta float64
+	if l < cfg.BandLow {
+		mintRaw := cfg.PhiTarget*oi - sTemp
+		if mintRaw < 0 {
+			mintRaw = 0
+		}
+		damping := math.Pow(cfg.PhiTarget, sTemp/mcap)
+		delta = mintRaw * damping
+		trace.MintRaw = mintRaw
+		trace.MintDamped = delta
+	} else if l >= cfg.BandHigh {
+		delta = 0
+	}
+
+	sNew := sTemp + delta
+	if sNew > mcap {
+		trace.ClampedCap = true
+		delta = mcap - sTemp
+		sNew = mcap
+	}
+
+	trace.Delta = delta
+	trace.SNew = sNew
+
+	// Audit hash chain (chained SHA256: previous hash + trace JSON)
+	trace.HashChainRoot = HashTrace(prevHashChainRoot, trace)
+
+	return sNew, trace
+}
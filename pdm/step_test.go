@@ -0,0 +1,75 @@
+package pdm
+
+import (
+	"testing"
+	"time"
+)
+
+// Guardrail test: the packaged core must keep the whitepaper bounds (Theorems 1 and 2).
+func TestStepPDM_Bounds(t *testing.T) {
+	mcap := 1000000.0
+	cfg := DefaultConfig(mcap)
+	if err := ValidatePDMConfig(cfg, mcap); err != nil {
+		t.Fatalf("default config invalid: %v", err)
+	}
+
+	heavy := cfg
+	heavy.BurnBase = 1
+	heavy.BurnVelocityK = 0
+	sNew, tr := StepPDM(100, 1000000, 1000, mcap, "", heavy)
+	if sNew < 0 || !tr.ClampedS {
+		t.Fatalf("expected non-negative clamped supply, got s_new=%f clamped_s=%t", sNew, tr.ClampedS)
+	}
+
+	sNew, tr = StepPDM(950000, 50000000, 100, mcap, "", cfg)
+	if sNew > mcap || !tr.ClampedCap {
+		t.Fatalf("expected supply capped at %f, got s_new=%f clamped_cap=%t", mcap, sNew, tr.ClampedCap)
+	}
+}
+
+func TestStepPDM_MintOnlyBelowBand(t *testing.T) {
+	mcap := 1000000.0
+	cfg := DefaultConfig(mcap)
+
+	_, tr := StepPDM(400000, 1000000, 0, mcap, "", cfg)
+	if tr.Delta <= 0 {
+		t.Fatalf("expected mint below band, got delta=%f (L=%f)", tr.Delta, tr.L)
+	}
+	_, tr = StepPDM(650000, 1000000, 0, mcap, "", cfg)
+	if tr.Delta != 0 {
+		t.Fatalf("expected no mint above band, got delta=%f (L=%f)", tr.Delta, tr.L)
+	}
+}
+
+// Replaying the same inputs at the same step time must reproduce the chain byte for byte.
+func TestStepPDMAt_Deterministic(t *testing.T) {
+	mcap := 1000000.0
+	cfg := DefaultConfig(mcap)
+	at := time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	run := func() []string {
+		var roots []string
+		s, prev := 618000.0, ""
+		for i := 0; i < 5; i++ {
+			var tr StepTrace
+			s, tr = StepPDMAt(at.AddDate(0, 0, i), s, 1000000, 50000, mcap, prev, cfg)
+			prev = tr.HashChainRoot
+			roots = append(roots, prev)
+		}
+		return roots
+	}
+
+	a, b := run(), run()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("step %d: replay produced %s, want %s", i+1, b[i], a[i])
+		}
+	}
+
+	_, tr1 := StepPDMWithClock(FixedClock(at), 618000, 1000000, 50000, mcap, "", cfg)
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	_, tr2 := StepPDMAt(at.In(loc), 618000, 1000000, 50000, mcap, "", cfg)
+	if tr1.HashChainRoot != tr2.HashChainRoot {
+		t.Fatalf("same instant in different zones hashed differently: %s vs %s", tr1.HashChainRoot, tr2.HashChainRoot)
+	}
+}
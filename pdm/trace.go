@@ -0,0 +1,179 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/pdm/trace.go
+// StepTrace: the audited record of a single PDM step
+
+package pdm
+
+import "time"
+
+type StepTrace struct {
+	Timestamp     time.Time `json:"timestamp"`
+	SPrev         float64   `json:"s_prev"`
+	Oi            float64   `json:"o_i"`
+	VTotal        float64   `json:"v_total"`
+	MCap          float64   `json:"m_cap"`
+	PhiTarget     float64   `json:"phi_target"`
+	BandLow       float64   `json:"band_low"`
+	BandHigh      float64   `json:"band_high"`
+	BurnBase      float64   `json:"burn_base"`
+	BurnVelocityK float64   `json:"burn_velocity_k"`
+
+	Velocity   float64 `json:"velocity"`
+	BurnRate   float64 `json:"burn_rate"`
+	BurnAmount float64 `json:"burn_amount"`
+	STemp      float64 `json:"s_temp"`
+	L          float64 `json:"l"`
+	MintRaw    float64 `json:"mint_raw"`
+	MintDamped float64 `json:"mint_damped"`
+	Delta      float64 `json:"delta"`
+	SNew       float64 `json:"s_new"`
+
+	ClampedS      bool   `json:"clamped_s"`
+	ClampedCap    bool   `json:"clamped_cap"`
+	Error         string `json:"error,omitempty"`
+	HashChainRoot string `json:"hash_chain_root"`
+
+	// Telemetry annotates how Oi and V were obtained. It is hashed with the
+	// trace but is not computed by the control law; replay copies it.
+	Telemetry *TelemetryInfo `json:"telemetry,omitempty"`
+}
+
+// TelemetryInfo records how a step's inputs were obtained.
+type TelemetryInfo struct {
+	// Provenance identifies the source and the raw inputs.
+	Provenance *Provenance `json:"provenance,omitempty"`
+	// Policy is the telemetry.on_failure policy that supplied the inputs
+	// after the source failed, and Reason the failure.
+	Policy string `json:"policy,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// HeldFrom is the timestamp of the step whose Oi and V were reused.
+	HeldFrom *time.Time `json:"held_from,omitempty"`
+	// Aggregation summarises the events accumulated into Oi and V.
+	Aggregation *Aggregation `json:"aggregation,omitempty"`
+	// Queries are the source queries that produced Oi and V.
+	Queries []SourceQuery `json:"queries,omitempty"`
+	// Quarantine is set when the inputs failed the sanity gate and an
+	// operator confirmed them, or corrected values, before the step ran.
+	Quarantine *QuarantineInfo `json:"quarantine,omitempty"`
+	// Quorum records every source's reading when Oi and V were aggregated
+	// from several sources.
+	Quorum *QuorumInfo `json:"quorum,omitempty"`
+	// Submitters are the key ids of the signed submissions the values came
+	// from.
+	Submitters []string `json:"submitters,omitempty"`
+	// Combined lists the missed slots whose V was added into this step under
+	// the "combined" catch-up policy.
+	Combined []CombinedSlot `json:"combined,omitempty"`
+}
+
+// CombinedSlot is a missed slot's V carried into a later step.
+type CombinedSlot struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+	V           float64   `json:"v"`
+}
+
+// Provenance records where a step's inputs came from. OiRaw and VRaw are
+// the values as supplied; the trace's Oi and VTotal are the ones the control
+// law used, after the MinO floor. Audits replay the step from the raw
+// values.
+type Provenance struct {
+	Mode   string  `json:"mode"`
+	Source string  `json:"source"` // e.g. signer key ids, CSV file and line, URL
+	OiRaw  float64 `json:"o_i_raw"`
+	VRaw   float64 `json:"v_raw"`
+	// SubmittedAt is when the values were received, or fetched from a pull
+	// source; PayloadHash the SHA-256 of the payload that carried them.
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	PayloadHash string     `json:"payload_sha256,omitempty"`
+}
+
+// QuarantineInfo records the operator's override of the sanity gate.
+type QuarantineInfo struct {
+	Reasons     []string  `json:"reasons"`
+	Oi          float64   `json:"quarantined_oi"`
+	V           float64   `json:"quarantined_v"`
+	ConfirmedAt time.Time `json:"confirmed_at"`
+	Corrected   bool      `json:"corrected,omitempty"`
+	// ConfirmedBy is the key id that signed the confirmation, if signed.
+	ConfirmedBy string `json:"confirmed_by,omitempty"`
+}
+
+// QuorumInfo records how several sources' readings were combined.
+type QuorumInfo struct {
+	Method    string          `json:"method"` // "median" or "agreement"
+	K         int             `json:"k"`      // readings required
+	Tolerance float64         `json:"tolerance,omitempty"`
+	Sources   []SourceReading `json:"sources"`
+}
+
+// SourceReading is one quorum source's reading for a step. Used marks the
+// readings the step's Oi and V were taken from.
+type SourceReading struct {
+	Name      string         `json:"name"`
+	Mode      string         `json:"mode"`
+	Oi        float64        `json:"o_i,omitempty"`
+	V         float64        `json:"v,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Used      bool           `json:"used"`
+	Telemetry *TelemetryInfo `json:"telemetry,omitempty"`
+}
+
+// SourceQuery is a query a pull source ran for a step, and its result.
+type SourceQuery struct {
+	Target     string    `json:"target"` // "oi" or "v"
+	Query      string    `json:"query"`
+	Value      float64   `json:"value"`
+	ResultTime time.Time `json:"result_time"`
+}
+
+// Aggregation describes a step window built from a stream of telemetry
+// events: V is the sum of the posted increments and Oi the configured gauge
+// of the posted readings.
+type Aggregation struct {
+	Events int `json:"events"`
+	// OiGauge is how Oi was derived: "last", "max" or "twa" (time-weighted
+	// average from the first event to the step).
+	OiGauge string    `json:"oi_gauge"`
+	OiLast  float64   `json:"oi_last"`
+	OiMax   float64   `json:"oi_max"`
+	OiTWA   float64   `json:"oi_twa"`
+	VSum    float64   `json:"v_sum"`
+	First   time.Time `json:"first_event"`
+	Last    time.Time `json:"last_event"`
+}
+
+// Annotate attaches info to the trace and reseals it on prevRoot.
+func (t *StepTrace) Annotate(prevRoot string, info *TelemetryInfo) {
+	t.Telemetry = info
+	t.HashChainRoot = HashTrace(prevRoot, *t)
+}
+
+// SupplyAfter is the pool supply once the step has been applied. A trace
+// that carries an Error did not transition state, so S stays at SPrev.
+func (t StepTrace) SupplyAfter() float64 {
+	if t.Error != "" {
+		return t.SPrev
+	}
+	return t.SNew
+}
@@ -0,0 +1,186 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/pdm/verify.go
+// Hash chain recomputation and per-step accounting checks
+
+package pdm
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// HashTrace computes the chain root of trace given the previous root:
+// SHA-256 over prevRoot followed by the trace JSON with HashChainRoot cleared.
+func HashTrace(prevRoot string, trace StepTrace) string {
+	trace.HashChainRoot = ""
+	return hashRecord(prevRoot, trace)
+}
+
+func hashRecord(prevRoot string, record interface{}) string {
+	recordJSON, _ := json.Marshal(record)
+	h := sha256.New()
+	h.Write([]byte(prevRoot + string(recordJSON)))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// ChainBreak describes the first entry whose stored root does not match
+// the recomputed one.
+type ChainBreak struct {
+	Index     int       `json:"index"`
+	Timestamp time.Time `json:"timestamp"`
+	PrevRoot  string    `json:"prev_root"`
+	Expected  string    `json:"expected_root"`
+	Actual    string    `json:"actual_root"`
+}
+
+// AccountingError describes a trace that violates
+// SNew = max(SPrev - BurnAmount, 0) + Delta, or for a capped step
+// Delta = MCap - STemp.
+type AccountingError struct {
+	Index     int       `json:"index"`
+	Timestamp time.Time `json:"timestamp"`
+	Field     string    `json:"field"`
+	Expected  float64   `json:"expected"`
+	Actual    float64   `json:"actual"`
+}
+
+// ChainReport is the result of VerifyChain and VerifyEntries. Entries counts
+// every chain entry; Steps, ParamChanges and Skipped how many of them are
+// step traces, parameter changes and skipped steps.
+type ChainReport struct {
+	Valid            bool              `json:"valid"`
+	Entries          int               `json:"entries"`
+	Steps            int               `json:"steps"`
+	ParamChanges     int               `json:"param_changes,omitempty"`
+	Skipped          int               `json:"skipped,omitempty"`
+	LinksVerified    int               `json:"links_verified"`
+	BrokenLinks      int               `json:"broken_links"`
+	AnchorRoot       string            `json:"anchor_root"`
+	HeadRoot         string            `json:"head_root"`
+	FirstBreak       *ChainBreak       `json:"first_break,omitempty"`
+	AccountingErrors []AccountingError `json:"accounting_errors,omitempty"`
+}
+
+// VerifyChain recomputes every HashChainRoot in traces, starting from
+// anchorRoot, and checks the accounting identity of each step.
+func VerifyChain(traces []StepTrace, anchorRoot string) ChainReport {
+	return VerifyEntries(StepEntries(traces), anchorRoot)
+}
+
+// VerifyEntries recomputes every HashChainRoot in a chain of steps,
+// parameter changes and skipped steps, starting from anchorRoot, and checks
+// the accounting identity of each step. Links after a break are checked
+// against the stored root so that every tampered entry is counted, but only
+// the first break is reported in detail.
+func VerifyEntries(entries []Entry, anchorRoot string) ChainReport {
+	report := ChainReport{
+		Valid:      true,
+		Entries:    len(entries),
+		AnchorRoot: anchorRoot,
+		HeadRoot:   anchorRoot,
+	}
+
+	prev := anchorRoot
+	for i, e := range entries {
+		if e.Step != nil {
+			report.Steps++
+		}
+		if e.ParamChange != nil {
+			report.ParamChanges++
+		}
+		if e.Skipped != nil {
+			report.Skipped++
+		}
+		expected := e.Hash(prev)
+		if expected == e.Root() {
+			if report.FirstBreak == nil {
+				report.LinksVerified++
+			}
+		} else {
+			report.Valid = false
+			report.BrokenLinks++
+			if report.FirstBreak == nil {
+				report.FirstBreak = &ChainBreak{
+					Index:     i,
+					Timestamp: e.Time(),
+					PrevRoot:  prev,
+					Expected:  expected,
+					Actual:    e.Root(),
+				}
+			}
+		}
+		prev = e.Root()
+
+		if e.Step == nil {
+			continue
+		}
+		if errs := CheckAccounting(i, *e.Step); len(errs) > 0 {
+			report.Valid = false
+			report.AccountingErrors = append(report.AccountingErrors, errs...)
+		}
+	}
+	report.HeadRoot = prev
+	return report
+}
+
+// CheckAccounting verifies that trace i satisfies the step identity
+// STemp = max(SPrev - BurnAmount, 0) and SNew = STemp + Delta; a capped step
+// must have SNew = MCap and Delta = MCap - STemp. Traces that carry an Error
+// did not transition state and are not checked.
+func CheckAccounting(i int, tr StepTrace) []AccountingError {
+	if tr.Error != "" {
+		return nil
+	}
+	var errs []AccountingError
+	check := func(field string, expected, actual float64) {
+		if !approxEqual(expected, actual) {
+			errs = append(errs, AccountingError{
+				Index:     i,
+				Timestamp: tr.Timestamp,
+				Field:     field,
+				Expected:  expected,
+				Actual:    actual,
+			})
+		}
+	}
+
+	sTemp := math.Max(tr.SPrev-tr.BurnAmount, 0)
+	check("s_temp", sTemp, tr.STemp)
+	if tr.ClampedCap {
+		check("s_new", tr.MCap, tr.SNew)
+		check("delta", tr.MCap-tr.STemp, tr.Delta)
+	} else {
+		check("s_new", tr.STemp+tr.Delta, tr.SNew)
+	}
+	return errs
+}
+
+// approxEqual tolerates the rounding introduced by the cap clamp
+// (delta = mcap - sTemp, sNew = sTemp + delta).
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
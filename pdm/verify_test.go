@@ -0,0 +1,100 @@
+package pdm
+
+import (
+	"testing"
+	"time"
+)
+
+// buildChain steps a default 1,000,000 cap pool daily from 2026-01-01,
+// starting at supply s and chained from anchor.
+func buildChain(n int, anchor string, s float64) []StepTrace {
+	mcap := 1000000.0
+	cfg := DefaultConfig(mcap)
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var traces []StepTrace
+	prev := anchor
+	for i := 0; i < n; i++ {
+		var tr StepTrace
+		s, tr = StepPDMAt(at.AddDate(0, 0, i), s, 1000000, 80000, mcap, prev, cfg)
+		prev = tr.HashChainRoot
+		traces = append(traces, tr)
+	}
+	return traces
+}
+
+func TestVerifyChain_Valid(t *testing.T) {
+	traces := buildChain(10, "", 400000)
+	rep := VerifyChain(traces, "")
+	if !rep.Valid || rep.LinksVerified != 10 || rep.HeadRoot != traces[9].HashChainRoot {
+		t.Fatalf("expected valid chain of 10, got %+v", rep)
+	}
+}
+
+func TestVerifyChain_DetectsTampering(t *testing.T) {
+	traces := buildChain(10, "", 400000)
+	traces[4].VTotal += 1
+
+	rep := VerifyChain(traces, "")
+	if rep.Valid || rep.FirstBreak == nil {
+		t.Fatalf("expected broken chain, got %+v", rep)
+	}
+	if rep.FirstBreak.Index != 4 || rep.LinksVerified != 4 || rep.BrokenLinks != 1 {
+		t.Fatalf("expected single break at index 4, got %+v", rep)
+	}
+	if rep.FirstBreak.Actual != traces[4].HashChainRoot {
+		t.Fatalf("actual root should be the stored one, got %s", rep.FirstBreak.Actual)
+	}
+}
+
+func TestVerifyChain_Accounting(t *testing.T) {
+	traces := buildChain(3, "", 400000)
+	// Rewrite the supply and re-seal the link so only the accounting check can catch it.
+	traces[1].SNew += 1000
+	traces[1].HashChainRoot = HashTrace(traces[0].HashChainRoot, traces[1])
+	traces[2].HashChainRoot = HashTrace(traces[1].HashChainRoot, traces[2])
+
+	rep := VerifyChain(traces, "")
+	if rep.Valid || rep.FirstBreak != nil {
+		t.Fatalf("expected intact links with accounting failure, got %+v", rep)
+	}
+	if len(rep.AccountingErrors) != 1 || rep.AccountingErrors[0].Index != 1 || rep.AccountingErrors[0].Field != "s_new" {
+		t.Fatalf("expected one s_new accounting error at index 1, got %+v", rep.AccountingErrors)
+	}
+}
+
+func TestAudit_ReplaysFromProvenanceRawInputs(t *testing.T) {
+	mcap := 1000000.0
+	cfg := DefaultConfig(mcap)
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, tr := StepPDMAt(at, 400000, cfg.MinO/10, 80000, mcap, "", cfg)
+	tr.Annotate("", &TelemetryInfo{Provenance: &Provenance{Mode: "manual", Source: "api", OiRaw: cfg.MinO / 10, VRaw: 80000}})
+	if tr.Oi != cfg.MinO {
+		t.Fatalf("expected Oi floored to MinO, got %g", tr.Oi)
+	}
+	if rep := Audit([]StepTrace{tr}, "", &cfg); !rep.Valid {
+		t.Fatalf("expected valid audit, got %+v", rep)
+	}
+
+	// Raw inputs that do not lead to the recorded ones fail the replay,
+	// even with the link resealed.
+	tr.Telemetry.Provenance.VRaw = 90000
+	tr.HashChainRoot = HashTrace("", tr)
+	if rep := Audit([]StepTrace{tr}, "", &cfg); rep.Valid || len(rep.ReplayMismatches) == 0 {
+		t.Fatalf("expected a replay mismatch for altered raw V, got %+v", rep)
+	}
+}
+
+func TestCheckAccounting_CappedDelta(t *testing.T) {
+	mcap := 1000000.0
+	_, tr := StepPDMAt(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 900000, 5000000, 50000, mcap, "", DefaultConfig(mcap))
+	if !tr.ClampedCap {
+		t.Fatalf("expected a capped step, got %+v", tr)
+	}
+	if errs := CheckAccounting(0, tr); len(errs) != 0 {
+		t.Fatalf("expected a valid capped step, got %+v", errs)
+	}
+	tr.Delta += 500
+	if errs := CheckAccounting(0, tr); len(errs) != 1 || errs[0].Field != "delta" {
+		t.Fatalf("expected a delta accounting error, got %+v", errs)
+	}
+}
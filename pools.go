@@ -0,0 +1,135 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/pools.go
+// Pool registry and the /pdm/v1/pools/{id}/... routes
+
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// pools lists every pool in config order. pools[0] also serves the
+// unprefixed routes (/pdm/v1/state, /api/telemetry, ...) that predate
+// multi-pool support.
+var pools []*Pool
+
+// poolByID returns the pool with the given id, or nil.
+func poolByID(id string) *Pool {
+	for _, p := range pools {
+		if p.Spec.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// routes maps the per-pool endpoints, relative to /pdm/v1/pools/{id}/.
+func (p *Pool) routes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"state":              p.stateHandler,
+		"history":            p.historyHandler,
+		"events":             p.eventsHandler,
+		"config":             p.configHandler,
+		"audit/verify":       p.auditVerifyHandler,
+		"audit/export":       p.auditExportHandler,
+		"params":             p.paramsHandler,
+		"telemetry":          p.telemetryHandler,
+		"telemetry/backfill": p.backfillHandler,
+		"quarantine":         p.quarantineHandler,
+	}
+}
+
+func registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/pdm/v1/pools", poolsHandler)
+	mux.HandleFunc("/pdm/v1/pools/", poolRouter)
+
+	if len(pools) == 0 {
+		return
+	}
+	d := pools[0]
+	mux.HandleFunc("/pdm/v1/state", d.stateHandler)
+	mux.HandleFunc("/pdm/v1/history", d.historyHandler)
+	mux.HandleFunc("/pdm/v1/events", d.eventsHandler)
+	mux.HandleFunc("/pdm/v1/config", d.configHandler)
+	mux.HandleFunc("/pdm/v1/audit/verify", d.auditVerifyHandler)
+	mux.HandleFunc("/pdm/v1/audit/export", d.auditExportHandler)
+	mux.HandleFunc("/pdm/v1/params", d.paramsHandler)
+	mux.HandleFunc("/pdm/v1/quarantine", d.quarantineHandler)
+	mux.HandleFunc("/api/telemetry", d.telemetryHandler)
+	mux.HandleFunc("/api/telemetry/backfill", d.backfillHandler)
+}
+
+// poolRouter dispatches /pdm/v1/pools/{id}/{endpoint}.
+func poolRouter(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/pdm/v1/pools/")
+	id, endpoint, _ := strings.Cut(rest, "/")
+	p := poolByID(id)
+	if p == nil {
+		writeJSONError(w, http.StatusNotFound, "unknown pool")
+		return
+	}
+	h, ok := p.routes()[strings.TrimSuffix(endpoint, "/")]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "unknown endpoint")
+		return
+	}
+	h(w, r)
+}
+
+// poolsHandler lists the pools served by this process.
+func poolsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "only GET allowed")
+		return
+	}
+	type poolSummary struct {
+		ID            string  `json:"id"`
+		Name          string  `json:"name"`
+		Unit          string  `json:"unit"`
+		TelemetryMode string  `json:"telemetry_mode"`
+		S             float64 `json:"s_current"`
+		MCap          float64 `json:"m_cap"`
+		Steps         int     `json:"steps"`
+		Halted        string  `json:"halted,omitempty"`
+		Quarantined   bool    `json:"quarantined,omitempty"`
+	}
+	out := make([]poolSummary, 0, len(pools))
+	for _, p := range pools {
+		p.mu.RLock()
+		out = append(out, poolSummary{
+			ID:            p.Spec.ID,
+			Name:          p.Spec.Name,
+			Unit:          p.Spec.Resource.Unit,
+			TelemetryMode: p.Spec.Telemetry.Mode,
+			S:             p.state.S,
+			MCap:          p.state.MCap,
+			Steps:         len(p.state.History),
+			Halted:        p.halted,
+			Quarantined:   p.quarantine != nil,
+		})
+		p.mu.RUnlock()
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"pools": out})
+}
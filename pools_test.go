@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPoolRouter_DispatchesByID(t *testing.T) {
+	a, b := testPool(t), testPool(t)
+	a.Spec.ID, b.Spec.ID = "compute", "storage"
+	a.state.S, b.state.S = 1, 2
+	saved := pools
+	pools = []*Pool{a, b}
+	defer func() { pools = saved }()
+
+	mux := http.NewServeMux()
+	registerRoutes(mux)
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec, body
+	}
+
+	if _, body := get("/pdm/v1/pools/storage/state"); body["s_current"] != 2.0 {
+		t.Fatalf("expected storage pool state, got %v", body)
+	}
+	if _, body := get("/pdm/v1/state"); body["s_current"] != 1.0 {
+		t.Fatalf("unprefixed route should serve the first pool, got %v", body)
+	}
+	if rec, _ := get("/pdm/v1/pools/nope/state"); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown pool: expected 404, got %d", rec.Code)
+	}
+	if _, body := get("/pdm/v1/pools"); len(body["pools"].([]interface{})) != 2 {
+		t.Fatalf("expected two pools listed, got %v", body)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/promsource.go
+// Pull telemetry source: PromQL instant queries against a Prometheus HTTP API
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// PrometheusTelemetry evaluates one instant query for Oi and one for V at
+// the step time.
+type PrometheusTelemetry struct {
+	cfg PrometheusSourceConfig
+	get *httpGetter
+}
+
+func newPrometheusTelemetry(cfg PrometheusSourceConfig) (*PrometheusTelemetry, error) {
+	get, err := newHTTPGetter("telemetry.prometheus", cfg.Headers, cfg.Timeout, cfg.Retries, cfg.RetryBackoff, cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return &PrometheusTelemetry{cfg: cfg, get: get}, nil
+}
+
+// FetchAt runs both queries evaluated at `at`. The queries and their result
+// timestamps are returned for the trace, with provenance hashing both
+// responses in order.
+func (p *PrometheusTelemetry) FetchAt(at time.Time) (float64, float64, *pdm.TelemetryInfo, error) {
+	info := &pdm.TelemetryInfo{}
+	h := sha256.New()
+	var values [2]float64
+	for i, q := range []struct{ target, expr string }{{"oi", p.cfg.OiQuery}, {"v", p.cfg.VQuery}} {
+		v, ts, err := p.query(q.expr, at, h)
+		if err != nil {
+			return 0, 0, nil, fmt.Errorf("prometheus %s query: %v", q.target, err)
+		}
+		values[i] = v
+		info.Queries = append(info.Queries, pdm.SourceQuery{Target: q.target, Query: q.expr, Value: v, ResultTime: ts})
+	}
+	fetched := time.Now().UTC()
+	info.Provenance = &pdm.Provenance{Mode: "prometheus", Source: redactURL(p.cfg.URL), SubmittedAt: &fetched, PayloadHash: hex.EncodeToString(h.Sum(nil))}
+	return values[0], values[1], info, nil
+}
+
+// query runs an instant query and requires a single non-negative scalar: a
+// scalar result or a one-element vector. The response body is written to
+// payload.
+func (p *PrometheusTelemetry) query(expr string, at time.Time, payload io.Writer) (float64, time.Time, error) {
+	params := url.Values{}
+	params.Set("query", expr)
+	params.Set("time", strconv.FormatFloat(float64(at.UnixMilli())/1000, 'f', -1, 64))
+	body, err := p.get.Get(strings.TrimRight(p.cfg.URL, "/") + "/api/v1/query?" + params.Encode())
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	payload.Write(body)
+
+	var resp struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, time.Time{}, fmt.Errorf("invalid response: %v", err)
+	}
+	if resp.Status != "success" {
+		return 0, time.Time{}, fmt.Errorf("status %q: %s", resp.Status, resp.Error)
+	}
+
+	var sample []interface{}
+	switch resp.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(resp.Data.Result, &sample); err != nil {
+			return 0, time.Time{}, fmt.Errorf("invalid scalar: %v", err)
+		}
+	case "vector":
+		var vec []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		}
+		if err := json.Unmarshal(resp.Data.Result, &vec); err != nil {
+			return 0, time.Time{}, fmt.Errorf("invalid vector: %v", err)
+		}
+		if len(vec) != 1 {
+			return 0, time.Time{}, fmt.Errorf("expected a single series, got %d", len(vec))
+		}
+		sample = vec[0].Value
+	default:
+		return 0, time.Time{}, fmt.Errorf("unsupported result type %q", resp.Data.ResultType)
+	}
+	return parseSample(sample)
+}
+
+// parseSample decodes a Prometheus [<unix seconds>, "<value>"] pair.
+func parseSample(sample []interface{}) (float64, time.Time, error) {
+	if len(sample) != 2 {
+		return 0, time.Time{}, fmt.Errorf("malformed sample")
+	}
+	secs, ok := sample[0].(float64)
+	s, ok2 := sample[1].(string)
+	if !ok || !ok2 {
+		return 0, time.Time{}, fmt.Errorf("malformed sample")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, time.Time{}, fmt.Errorf("value %q is not a finite number", s)
+	}
+	if v < 0 {
+		return 0, time.Time{}, fmt.Errorf("value %g is negative", v)
+	}
+	ts := time.UnixMilli(int64(math.Round(secs * 1000))).UTC()
+	return v, ts, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// promStandIn answers /api/v1/query with the canned result for each query.
+func promStandIn(t *testing.T, results map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		res, ok := results[r.URL.Query().Get("query")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":%s}`, strings.ReplaceAll(res, "TIME", r.URL.Query().Get("time")))
+	}))
+}
+
+func TestPrometheusTelemetry_RecordsQueriesInTrace(t *testing.T) {
+	srv := promStandIn(t, map[string]string{
+		`sum(obligations)`:          `{"resultType":"vector","result":[{"metric":{},"value":[TIME,"1000000"]}]}`,
+		`increase(activity[1d])`:    `{"resultType":"scalar","result":[TIME,"50000"]}`,
+		`activity_by_region`:        `{"resultType":"vector","result":[{"metric":{"r":"a"},"value":[TIME,"1"]},{"metric":{"r":"b"},"value":[TIME,"2"]}]}`,
+		`delta(activity[1d])`:       `{"resultType":"vector","result":[{"metric":{},"value":[TIME,"-5"]}]}`,
+		`sum(obligations) / 0`:      `{"resultType":"vector","result":[{"metric":{},"value":[TIME,"NaN"]}]}`,
+		`absent_metric{job="none"}`: `{"resultType":"vector","result":[]}`,
+	})
+	defer srv.Close()
+
+	day := func(n int) time.Time { return time.Date(2026, 3, n, 0, 0, 0, 0, time.UTC) }
+	p := testPoolWithGenesis(t, day(1))
+	p.Spec.Telemetry.Mode = "prometheus"
+	cfg := PrometheusSourceConfig{URL: srv.URL, OiQuery: `sum(obligations)`, VQuery: `increase(activity[1d])`}
+	p.prom, _ = newPrometheusTelemetry(cfg)
+
+	p.runScheduled(day(2))
+	h := p.state.History
+	if len(h) != 1 || h[0].Oi != 1000000 || h[0].VTotal != 50000 {
+		t.Fatalf("expected a step on the queried values, got %+v", p.state.Journal)
+	}
+	q := h[0].Telemetry.Queries
+	if len(q) != 2 || q[1].Query != cfg.VQuery || !q[1].ResultTime.Equal(day(2)) {
+		t.Fatalf("queries not recorded with the step-time result: %+v", q)
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, p.state.Journal); !rep.Valid {
+		t.Fatalf("queried step failed audit: %+v", rep)
+	}
+
+	for _, bad := range []string{`activity_by_region`, `delta(activity[1d])`, `sum(obligations) / 0`, `absent_metric{job="none"}`, `syntax error(`} {
+		cfg.VQuery = bad
+		p.prom, _ = newPrometheusTelemetry(cfg)
+		if _, _, _, err := p.prom.FetchAt(day(3)); err == nil {
+			t.Errorf("%s: expected rejection", bad)
+		}
+	}
+}
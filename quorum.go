@@ -0,0 +1,154 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/quorum.go
+// Quorum telemetry: combine several sources' readings by median or agreement
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// quorumMember is one source of a pool's telemetry quorum.
+type quorumMember struct {
+	name string
+	cfg  TelemetryConfig
+	telemetrySource
+}
+
+// submissionsFile is the member's store of posted telemetry, kept beside the
+// pool's own.
+func (m *quorumMember) submissionsFile() string {
+	return "telemetry_submissions." + m.name + ".json"
+}
+
+// fetchQuorum reads every quorum source for the step at `at` on date and
+// combines the readings. The error reports each failing source when too few
+// readings are usable.
+func (p *Pool) fetchQuorum(date string, at time.Time) (oi, v float64, info *pdm.TelemetryInfo, err error) {
+	readings := make([]pdm.SourceReading, len(p.quorum))
+	var wg sync.WaitGroup
+	for i, m := range p.quorum {
+		wg.Add(1)
+		go func(i int, m *quorumMember) {
+			defer wg.Done()
+			r := pdm.SourceReading{Name: m.name, Mode: m.cfg.Mode}
+			oi, v, info, err := m.fetch(m.cfg, date, at)
+			switch {
+			case err != nil:
+				r.Error = err.Error()
+			case oi <= 0:
+				r.Error = fmt.Sprintf("Oi must be > 0 (got %g)", oi)
+			case v < 0:
+				r.Error = fmt.Sprintf("V must be >= 0 (got %g)", v)
+			default:
+				r.Oi, r.V, r.Telemetry = oi, v, info
+			}
+			readings[i] = r
+		}(i, m)
+	}
+	wg.Wait()
+
+	q := p.Spec.Telemetry.Quorum
+	qi := &pdm.QuorumInfo{Method: q.Method, Sources: readings}
+	oi, v, err = aggregateQuorum(q, qi)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	var used []string
+	for _, r := range readings {
+		if r.Used {
+			used = append(used, r.Name)
+		}
+	}
+	prov := &pdm.Provenance{Mode: "quorum", Source: strings.Join(used, ","), OiRaw: oi, VRaw: v}
+	return oi, v, &pdm.TelemetryInfo{Quorum: qi, Provenance: prov}, nil
+}
+
+// aggregateQuorum combines the readings in qi according to q, marking the
+// ones used and recording the number required.
+func aggregateQuorum(q *QuorumConfig, qi *pdm.QuorumInfo) (oi, v float64, err error) {
+	var ok []int
+	var failed []string
+	for i, r := range qi.Sources {
+		if r.Error != "" {
+			failed = append(failed, r.Name+": "+r.Error)
+			continue
+		}
+		ok = append(ok, i)
+	}
+
+	used := ok
+	switch q.Method {
+	case "agreement":
+		qi.K, qi.Tolerance = q.K, q.Tolerance
+		// The largest set of readings that all agree with one of them.
+		var best []int
+		for _, c := range ok {
+			var cluster []int
+			for _, j := range ok {
+				if withinTolerance(qi.Sources[c].Oi, qi.Sources[j].Oi, q.Tolerance) &&
+					withinTolerance(qi.Sources[c].V, qi.Sources[j].V, q.Tolerance) {
+					cluster = append(cluster, j)
+				}
+			}
+			if len(cluster) > len(best) {
+				best = cluster
+			}
+		}
+		used = best
+	default:
+		qi.K = q.MinSources
+	}
+
+	if len(used) < qi.K {
+		detail := fmt.Sprintf("%d of %d sources usable", len(ok), len(qi.Sources))
+		if q.Method == "agreement" {
+			detail = fmt.Sprintf("%d of %d sources agree within %g", len(used), len(qi.Sources), q.Tolerance)
+		}
+		if len(failed) > 0 {
+			detail += " (" + strings.Join(failed, "; ") + ")"
+		}
+		return 0, 0, fmt.Errorf("quorum not met: %s, need %d", detail, qi.K)
+	}
+
+	ois := make([]float64, len(used))
+	vs := make([]float64, len(used))
+	for n, i := range used {
+		qi.Sources[i].Used = true
+		ois[n], vs[n] = qi.Sources[i].Oi, qi.Sources[i].V
+	}
+	return median(ois), median(vs), nil
+}
+
+// withinTolerance reports whether a and b differ by at most tol relative to
+// the larger of them.
+func withinTolerance(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol*math.Max(math.Abs(a), math.Abs(b))
+}
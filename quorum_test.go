@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+func TestAggregateQuorum(t *testing.T) {
+	readings := func() *pdm.QuorumInfo {
+		return &pdm.QuorumInfo{Sources: []pdm.SourceReading{
+			{Name: "a", Oi: 1000000, V: 50000},
+			{Name: "b", Oi: 1005000, V: 50200},
+			{Name: "c", Oi: 2000000, V: 90000},
+			{Name: "d", Error: "no telemetry submitted for 2026-03-02"},
+		}}
+	}
+
+	qi := readings()
+	oi, v, err := aggregateQuorum(&QuorumConfig{Method: "median", MinSources: 3}, qi)
+	if err != nil || oi != 1005000 || v != 50200 {
+		t.Fatalf("median: got %v %v %v", oi, v, err)
+	}
+	if !qi.Sources[2].Used || qi.Sources[3].Used {
+		t.Fatalf("median should use every reporting source: %+v", qi.Sources)
+	}
+	if _, _, err := aggregateQuorum(&QuorumConfig{Method: "median", MinSources: 4}, readings()); err == nil || !strings.Contains(err.Error(), "3 of 4 sources usable") {
+		t.Fatalf("median below min_sources: got %v", err)
+	}
+
+	qi = readings()
+	oi, v, err = aggregateQuorum(&QuorumConfig{Method: "agreement", K: 2, Tolerance: 0.01}, qi)
+	if err != nil || oi != 1002500 || v != 50100 || qi.Sources[2].Used {
+		t.Fatalf("agreement: got %v %v %v, sources %+v", oi, v, err, qi.Sources)
+	}
+	if _, _, err := aggregateQuorum(&QuorumConfig{Method: "agreement", K: 3, Tolerance: 0.01}, readings()); err == nil || !strings.Contains(err.Error(), "2 of 4 sources agree") {
+		t.Fatalf("agreement below k: got %v", err)
+	}
+}
+
+func TestQuorum_StepRecordsReadingsAndRefusesWithoutQuorum(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 3, n, 0, 0, 0, 0, time.UTC) }
+	base := testPoolWithGenesis(t, day(1))
+	spec := base.Spec
+	csvPath := filepath.Join(t.TempDir(), "telemetry.csv")
+	os.WriteFile(csvPath, []byte("date,oi,v\n2026-03-02,1000000,50000\n"), 0644)
+	spec.Telemetry = TelemetryConfig{Mode: "quorum", OnFailure: "skip", Quorum: &QuorumConfig{
+		Method: "agreement", K: 2, Tolerance: 0.01,
+		Sources: []QuorumSource{
+			{Name: "ops", TelemetryConfig: TelemetryConfig{Mode: "manual", AuthToken: "ops-secret"}},
+			{Name: "billing", TelemetryConfig: TelemetryConfig{Mode: "manual"}},
+			{Name: "export", TelemetryConfig: TelemetryConfig{Mode: "csv", CSVPath: csvPath}},
+		},
+	}}
+	p := newPool(spec)
+	p.open()
+	p.genesis, p.state = base.genesis, base.state
+
+	post := func(source, token, body string) int {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/telemetry?source="+source, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		p.telemetryHandler(rec, r)
+		return rec.Code
+	}
+	if code := post("ops", "", `{"oi":1002000,"v":50100,"date":"2026-03-02"}`); code != http.StatusUnauthorized {
+		t.Fatalf("source auth_token not enforced: %d", code)
+	}
+	if code := post("ops", "ops-secret", `{"oi":1002000,"v":50100,"date":"2026-03-02"}`); code != http.StatusOK {
+		t.Fatalf("ops submit: %d", code)
+	}
+	if code := post("billing", "", `{"oi":3000000,"v":50000,"date":"2026-03-02"}`); code != http.StatusOK {
+		t.Fatalf("billing submit: %d", code)
+	}
+	if code := post("export", "", `{"oi":1,"v":1}`); code != http.StatusMethodNotAllowed {
+		t.Fatalf("csv source accepted a POST: %d", code)
+	}
+	if code := post("", "", `{"oi":1,"v":1}`); code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST without ?source= accepted: %d", code)
+	}
+
+	p.runScheduled(day(2))
+	if len(p.state.Journal) != 1 || p.state.Journal[0].Step == nil {
+		t.Fatalf("expected a step, got %+v", p.state.Journal)
+	}
+	tr := p.state.Journal[0].Step
+	if tr.Oi != 1001000 || tr.VTotal != 50050 || tr.Telemetry == nil || tr.Telemetry.Quorum == nil {
+		t.Fatalf("expected median of the agreeing sources with quorum info, got %+v", tr)
+	}
+	if src := tr.Telemetry.Quorum.Sources; len(src) != 3 || !src[0].Used || src[1].Used || src[1].Oi != 3000000 || !src[2].Used {
+		t.Fatalf("per-source readings not kept: %+v", src)
+	}
+
+	// Only one source reports for Mar 3: the step is refused.
+	post("ops", "ops-secret", `{"oi":1002000,"v":50100,"date":"2026-03-03"}`)
+	p.runScheduled(day(3))
+	if sk := p.state.Journal[1].Skipped; sk == nil || !strings.Contains(sk.Reason, "quorum not met") {
+		t.Fatalf("expected a skip for the missing quorum, got %+v", p.state.Journal[1])
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, p.state.Journal); !rep.Valid {
+		t.Fatalf("quorum chain failed audit: %+v", rep)
+	}
+}
@@ -0,0 +1,346 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/sanity.go
+// Telemetry sanity gate and the quarantine of rejected values
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+const quarantineFile = "quarantine.json"
+
+// Quarantined is a step whose telemetry failed the sanity gate. It waits for
+// an operator to confirm or reject it until the next scheduled step.
+type Quarantined struct {
+	ScheduledAt   time.Time          `json:"scheduled_at"`
+	Oi            float64            `json:"oi"`
+	V             float64            `json:"v"`
+	Reasons       []string           `json:"reasons"`
+	Info          *pdm.TelemetryInfo `json:"info,omitempty"`
+	QuarantinedAt time.Time          `json:"quarantined_at"`
+}
+
+// checkSanity returns the reasons (oi, v) should not be stepped, judged
+// against recent, the pool's latest good steps oldest first. A nil cfg
+// passes everything.
+func checkSanity(cfg *SanityConfig, oi, v float64, recent []pdm.StepTrace) []string {
+	if cfg == nil {
+		return nil
+	}
+	var reasons []string
+	bound := func(name string, x float64, min, max *float64) {
+		if min != nil && x < *min {
+			reasons = append(reasons, fmt.Sprintf("%s %g below minimum %g", name, x, *min))
+		}
+		if max != nil && x > *max {
+			reasons = append(reasons, fmt.Sprintf("%s %g above maximum %g", name, x, *max))
+		}
+	}
+	bound("Oi", oi, cfg.OiMin, cfg.OiMax)
+	bound("V", v, cfg.VMin, cfg.VMax)
+
+	if len(recent) > 0 {
+		prev := recent[len(recent)-1]
+		change := func(name string, x, last, limit float64) {
+			if limit == 0 || last <= 0 || x <= 0 {
+				return
+			}
+			if r := x / last; r > limit || r < 1/limit {
+				reasons = append(reasons, fmt.Sprintf("%s %g is %.2fx the previous step's %g (max change %gx)", name, x, r, last, limit))
+			}
+		}
+		change("Oi", oi, prev.Oi, cfg.MaxOiChange)
+		change("V", v, prev.VTotal, cfg.MaxVChange)
+	}
+
+	if cfg.ZScore > 0 && len(recent) >= cfg.ZMinHistory {
+		window := recent
+		if len(window) > cfg.ZWindow {
+			window = window[len(window)-cfg.ZWindow:]
+		}
+		ois, vs := make([]float64, len(window)), make([]float64, len(window))
+		for i, tr := range window {
+			ois[i], vs[i] = tr.Oi, tr.VTotal
+		}
+		for _, c := range []struct {
+			name string
+			x    float64
+			hist []float64
+		}{{"Oi", oi, ois}, {"V", v, vs}} {
+			if z, ok := robustZ(c.x, c.hist); ok && math.Abs(z) > cfg.ZScore {
+				reasons = append(reasons, fmt.Sprintf("%s %g has robust z-score %.1f against the last %d steps (max %g)", c.name, c.x, z, len(c.hist), cfg.ZScore))
+			}
+		}
+	}
+	return reasons
+}
+
+// robustZ is the modified z-score 0.6745(x - median)/MAD. It is undefined
+// when the history has no spread.
+func robustZ(x float64, hist []float64) (float64, bool) {
+	med := median(hist)
+	dev := make([]float64, len(hist))
+	for i, h := range hist {
+		dev[i] = math.Abs(h - med)
+	}
+	mad := median(dev)
+	if mad == 0 {
+		return 0, false
+	}
+	return 0.6745 * (x - med) / mad, true
+}
+
+func median(xs []float64) float64 {
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// recentGoodSteps returns the latest steps that ran without error, oldest
+// first, enough for every sanity check. Callers hold p.mu.
+func (p *Pool) recentGoodSteps() []pdm.StepTrace {
+	n := 1
+	if sc := p.Spec.Telemetry.Sanity; sc != nil && sc.ZWindow > n {
+		n = sc.ZWindow
+	}
+	var out []pdm.StepTrace
+	for i := len(p.state.History) - 1; i >= 0 && len(out) < n; i-- {
+		if tr := p.state.History[i]; tr.Error == "" {
+			out = append(out, tr)
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// sanityCheck runs the pool's gate on (oi, v). Every caller quarantines or
+// skips values that fail it, so failures are counted as rejections here.
+func (p *Pool) sanityCheck(oi, v float64) []string {
+	p.mu.RLock()
+	reasons := checkSanity(p.Spec.Telemetry.Sanity, oi, v, p.recentGoodSteps())
+	p.mu.RUnlock()
+	if len(reasons) > 0 {
+		p.metrics.rejectedBySanity()
+	}
+	return reasons
+}
+
+// quarantineStep holds the step at `at` for operator confirmation instead of
+// applying it, and alerts.
+func (p *Pool) quarantineStep(at time.Time, oi, v float64, info *pdm.TelemetryInfo, reasons []string) {
+	q := &Quarantined{ScheduledAt: at, Oi: oi, V: v, Reasons: reasons, Info: info, QuarantinedAt: time.Now().UTC()}
+	p.mu.Lock()
+	p.quarantine = q
+	if err := p.saveQuarantine(); err != nil {
+		p.log.Printf("Quarantine write error: %v", err)
+	}
+	p.mu.Unlock()
+	sendAlert(p, "telemetry_quarantine", fmt.Sprintf("step at %s held for confirmation (Oi=%g, V=%g): %s",
+		at.Format(time.RFC3339), oi, v, strings.Join(reasons, "; ")))
+}
+
+// expireQuarantine records a quarantined step scheduled before `at` as
+// skipped: it was not confirmed before the next step became due.
+func (p *Pool) expireQuarantine(at time.Time) {
+	q := p.claimQuarantine()
+	if q == nil {
+		return
+	}
+	if !q.ScheduledAt.Before(at) {
+		p.restoreQuarantine(q)
+		return
+	}
+	p.skip(q.ScheduledAt, pdm.SkipPolicyQuarantine, "not confirmed before the next step: "+strings.Join(q.Reasons, "; "))
+}
+
+// claimQuarantine takes the pending quarantine, if any, so that only one of
+// the runner and an operator acts on it.
+func (p *Pool) claimQuarantine() *Quarantined {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	q := p.quarantine
+	if q != nil {
+		p.quarantine = nil
+		if err := p.saveQuarantine(); err != nil {
+			p.log.Printf("Quarantine write error: %v", err)
+		}
+	}
+	return q
+}
+
+func (p *Pool) restoreQuarantine(q *Quarantined) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.quarantine = q
+	if err := p.saveQuarantine(); err != nil {
+		p.log.Printf("Quarantine write error: %v", err)
+	}
+}
+
+// loadQuarantine restores a quarantine pending at shutdown.
+func (p *Pool) loadQuarantine() {
+	data, err := os.ReadFile(p.dataDir + "/" + quarantineFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			p.log.Fatalf("Quarantine read error: %v", err)
+		}
+		return
+	}
+	var q Quarantined
+	if err := json.Unmarshal(data, &q); err != nil {
+		p.log.Fatalf("Quarantine parse error: %v", err)
+	}
+	p.mu.Lock()
+	p.quarantine = &q
+	p.mu.Unlock()
+	p.log.Printf("Step at %s is quarantined awaiting confirmation", q.ScheduledAt.Format(time.RFC3339))
+}
+
+// saveQuarantine writes or removes quarantine.json. Callers hold p.mu.
+func (p *Pool) saveQuarantine() error {
+	path := p.dataDir + "/" + quarantineFile
+	if p.quarantine == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(p.quarantine, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// quarantineHandler serves /pdm/v1/quarantine.
+//
+//	GET     the quarantined step, or null
+//	POST    {"action": "confirm"|"reject", "scheduled_at"?, "oi"?, "v"?}
+//
+// confirm steps on the quarantined values, or on oi and v if both are given;
+// reject records the step as skipped.
+func (p *Pool) quarantineHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		p.mu.RLock()
+		q := p.quarantine
+		p.mu.RUnlock()
+		writeJSON(w, http.StatusOK, map[string]interface{}{"quarantined": q})
+
+	case http.MethodPost:
+		body, operator, ok := p.readOperatorRequest(w, r, 8*1024)
+		if !ok {
+			return
+		}
+		defer p.noteHealth()
+		var req struct {
+			Action      string     `json:"action"`
+			ScheduledAt *time.Time `json:"scheduled_at"`
+			Oi          *float64   `json:"oi"`
+			V           *float64   `json:"v"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+		if req.Action != "confirm" && req.Action != "reject" {
+			writeJSONError(w, http.StatusBadRequest, "action must be 'confirm' or 'reject'")
+			return
+		}
+		if (req.Oi == nil) != (req.V == nil) {
+			writeJSONError(w, http.StatusBadRequest, "give both oi and v to correct the values")
+			return
+		}
+		if req.Oi != nil && (*req.Oi <= 0 || *req.V < 0) {
+			writeJSONError(w, http.StatusBadRequest, "Oi must be > 0 and V must be >= 0")
+			return
+		}
+
+		// Claiming and then stepping or skipping the slot is one chain
+		// update, serialised with the runner and backfills.
+		p.stepMu.Lock()
+		defer p.stepMu.Unlock()
+		q := p.claimQuarantine()
+		if q == nil {
+			writeJSONError(w, http.StatusNotFound, "no step is quarantined")
+			return
+		}
+		if req.ScheduledAt != nil && !req.ScheduledAt.Equal(q.ScheduledAt) {
+			p.restoreQuarantine(q)
+			writeJSONError(w, http.StatusConflict, "the quarantined step is scheduled at "+q.ScheduledAt.Format(time.RFC3339))
+			return
+		}
+
+		if req.Action == "reject" {
+			by := "operator"
+			if operator != "" {
+				by += " " + operator
+			}
+			p.skip(q.ScheduledAt, pdm.SkipPolicyQuarantine, "rejected by "+by+": "+strings.Join(q.Reasons, "; "))
+			writeJSON(w, http.StatusOK, map[string]interface{}{"status": "rejected", "scheduled_at": q.ScheduledAt})
+			return
+		}
+
+		info := &pdm.TelemetryInfo{}
+		if q.Info != nil {
+			*info = *q.Info
+		}
+		info.Quarantine = &pdm.QuarantineInfo{Reasons: q.Reasons, Oi: q.Oi, V: q.V, ConfirmedAt: time.Now().UTC(), ConfirmedBy: operator}
+		oi, v := q.Oi, q.V
+		if req.Oi != nil {
+			oi, v = *req.Oi, *req.V
+			info.Quarantine.Corrected = true
+			info.Provenance = &pdm.Provenance{Mode: p.Spec.Telemetry.Mode, Source: "operator", SubmittedAt: &info.Quarantine.ConfirmedAt}
+		}
+		if err := p.step(q.ScheduledAt, oi, v, info); err != nil {
+			p.restoreQuarantine(q)
+			writeJSONError(w, http.StatusInternalServerError, "step failed; still quarantined")
+			return
+		}
+		p.log.Printf("Quarantined step at %s confirmed by operator (Oi=%g, V=%g)", q.ScheduledAt.Format(time.RFC3339), oi, v)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "confirmed", "scheduled_at": q.ScheduledAt, "oi": oi, "v": v})
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "only GET and POST allowed")
+	}
+}
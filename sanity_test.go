@@ -0,0 +1,193 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+func TestCheckSanity(t *testing.T) {
+	max := 2e6
+	var recent []pdm.StepTrace
+	for i := 0; i < 10; i++ {
+		recent = append(recent, pdm.StepTrace{Oi: 1000000 + float64(i%3)*10000, VTotal: 50000 + float64(i%4)*1000})
+	}
+	cfg := &SanityConfig{OiMax: &max, MaxVChange: 3, ZScore: 5, ZWindow: 30, ZMinHistory: 7}
+
+	if r := checkSanity(cfg, 1010000, 51000, recent); len(r) != 0 {
+		t.Fatalf("ordinary values rejected: %v", r)
+	}
+	if r := checkSanity(cfg, 1e7, 51000, recent); len(r) != 2 {
+		t.Fatalf("extra zero on Oi: expected bound and z-score rejections, got %v", r)
+	}
+	if r := checkSanity(cfg, 1010000, 200000, recent); len(r) != 2 || !strings.Contains(r[0], "max change 3x") {
+		t.Fatalf("V jump: expected change and z-score rejections, got %v", r)
+	}
+	if r := checkSanity(cfg, 1010000, 200000, recent[:5]); len(r) != 1 {
+		t.Fatalf("z-score needs zscore_min_history steps, got %v", r)
+	}
+	if r := checkSanity(nil, 1e12, 1e12, recent); r != nil {
+		t.Fatalf("no gate configured, got %v", r)
+	}
+}
+
+func quarantineDay(n int) time.Time { return time.Date(2026, 3, n, 0, 0, 0, 0, time.UTC) }
+
+func submitDay(p *Pool, date string, oi, v float64) {
+	p.manual.Submit(Submission{Date: date, Oi: oi, V: v, ReceivedAt: quarantineDay(1)})
+}
+
+func postQuarantine(p *Pool, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/pdm/v1/quarantine", strings.NewReader(body))
+	req.Header.Set("X-PDM-Token", "operator-secret")
+	p.quarantineHandler(rec, req)
+	return rec
+}
+
+// quarantinedPool steps 2026-03-02 and quarantines 2026-03-03, whose V has
+// an extra zero.
+func quarantinedPool(t *testing.T) *Pool {
+	t.Helper()
+	p := testPoolWithGenesis(t, quarantineDay(1))
+	p.Spec.Operator.AuthToken = "operator-secret"
+	p.Spec.Telemetry.Sanity = &SanityConfig{MaxVChange: 3, ZWindow: 30, ZMinHistory: 7}
+	submitDay(p, "2026-03-02", 1000000, 50000)
+	p.runScheduled(quarantineDay(2))
+	submitDay(p, "2026-03-03", 1000000, 500000)
+	p.runScheduled(quarantineDay(3))
+	return p
+}
+
+func TestQuarantine_ConfirmRejectAndExpire(t *testing.T) {
+	day, submit, post := quarantineDay, submitDay, postQuarantine
+	newGated := func() *Pool { return quarantinedPool(t) }
+
+	// Rejected values are held, not applied.
+	p := newGated()
+	if len(p.state.Journal) != 1 || p.quarantine == nil || !p.quarantine.ScheduledAt.Equal(day(3)) {
+		t.Fatalf("expected the day-3 step quarantined and nothing chained, journal=%d", len(p.state.Journal))
+	}
+
+	// Without an operator credential nobody can act on it.
+	p.Spec.Operator.AuthToken = ""
+	if rec := post(p, `{"action":"confirm","oi":1000000,"v":50000}`); rec.Code != http.StatusForbidden || p.quarantine == nil {
+		t.Fatalf("expected 403 with no credential configured, got %d", rec.Code)
+	}
+	p.Spec.Operator.AuthToken = "operator-secret"
+
+	// Confirm with corrected values steps the held slot.
+	if rec := post(p, `{"action":"confirm","oi":1000000,"v":50000}`); rec.Code != http.StatusOK {
+		t.Fatalf("confirm: %d %s", rec.Code, rec.Body)
+	}
+	h := p.state.History
+	if len(h) != 2 || !h[1].Timestamp.Equal(day(3)) || h[1].VTotal != 50000 || h[1].Telemetry.Quarantine == nil || !h[1].Telemetry.Quarantine.Corrected {
+		t.Fatalf("confirmed step not recorded as corrected: %+v", h)
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, p.state.Journal); !rep.Valid {
+		t.Fatalf("confirmed step failed audit: %+v", rep)
+	}
+	if rec := post(p, `{"action":"confirm"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("nothing left to confirm: expected 404, got %d", rec.Code)
+	}
+
+	// Reject records a skip.
+	p = newGated()
+	if rec := post(p, `{"action":"reject"}`); rec.Code != http.StatusOK {
+		t.Fatalf("reject: %d %s", rec.Code, rec.Body)
+	}
+	if j := p.state.Journal; len(j) != 2 || j[1].Skipped == nil || j[1].Skipped.Policy != pdm.SkipPolicyQuarantine {
+		t.Fatalf("expected a telemetry_quarantine skip, got %+v", j)
+	}
+
+	// Unconfirmed by the next step: skipped, then the next step runs.
+	p = newGated()
+	submit(p, "2026-03-04", 1000000, 52000)
+	p.runScheduled(day(4))
+	j := p.state.Journal
+	if len(j) != 3 || j[1].Skipped == nil || !j[1].Skipped.ScheduledAt.Equal(day(3)) || j[2].Step == nil || p.quarantine != nil {
+		t.Fatalf("expected skip for day 3 then step for day 4, got %+v", j)
+	}
+}
+
+func TestQuarantine_ConfirmSerialisedWithScheduledStep(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		p := quarantinedPool(t)
+		submitDay(p, "2026-03-04", 1000000, 52000)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			// As the daily runner does.
+			p.stepMu.Lock()
+			defer p.stepMu.Unlock()
+			p.runScheduled(quarantineDay(4))
+		}()
+		go func() {
+			defer wg.Done()
+			postQuarantine(p, `{"action":"confirm","oi":1000000,"v":50000}`)
+		}()
+		wg.Wait()
+
+		// Day 3 is stepped or expired, once, before day 4.
+		j := p.state.Journal
+		if len(j) != 3 || !j[1].Time().Equal(quarantineDay(3)) || j[2].Step == nil || !j[2].Step.Timestamp.Equal(quarantineDay(4)) {
+			t.Fatalf("expected day 3 then day 4 chained once each, got %d entries", len(j))
+		}
+		if rep := pdm.AuditFromGenesis(*p.genesis, j); !rep.Valid {
+			t.Fatalf("concurrent confirm forked the chain: %+v", rep)
+		}
+	}
+}
+
+func TestQuarantine_OperatorCredentialIsSeparate(t *testing.T) {
+	p := quarantinedPool(t)
+	p.Spec.Telemetry.AuthToken = "submitter-secret"
+	send := func(token string, sign bool) *httptest.ResponseRecorder {
+		const uri, body = "/pdm/v1/quarantine", `{"action":"confirm"}`
+		req := httptest.NewRequest(http.MethodPost, uri, strings.NewReader(body))
+		req.Header.Set("X-PDM-Token", token)
+		if sign {
+			ts := strconv.FormatInt(time.Now().Unix(), 10)
+			req.Header.Set(headerKeyID, "alice")
+			req.Header.Set(headerTimestamp, ts)
+			req.Header.Set(headerNonce, "n-1")
+			req.Header.Set(headerSignature, signSubmission("0123456789abcdef-alice", ts, "n-1", http.MethodPost, uri, []byte(body)))
+		}
+		rec := httptest.NewRecorder()
+		p.quarantineHandler(rec, req)
+		return rec
+	}
+
+	// The submitter whose values were held cannot release them.
+	if rec := send("submitter-secret", false); rec.Code != http.StatusForbidden || p.quarantine == nil {
+		t.Fatalf("telemetry token: expected 403, got %d", rec.Code)
+	}
+	// Nor can the operator's token submit telemetry.
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/telemetry", strings.NewReader(`{"oi":1000000,"v":50000}`))
+	req.Header.Set("X-PDM-Token", "operator-secret")
+	p.telemetryHandler(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("operator token on telemetry: expected 403, got %d", rec.Code)
+	}
+
+	// With both configured, the token alone is not enough.
+	p.Spec.Operator.Signing = &SigningConfig{Keys: map[string]string{"alice": "0123456789abcdef-alice"}, MaxSkew: "5m"}
+	if rec := send("operator-secret", false); rec.Code != http.StatusUnauthorized || p.quarantine == nil {
+		t.Fatalf("unsigned with signing configured: expected 401, got %d", rec.Code)
+	}
+	if rec := send("operator-secret", true); rec.Code != http.StatusOK {
+		t.Fatalf("signed confirm: %d %s", rec.Code, rec.Body)
+	}
+	if q := p.state.History[1].Telemetry.Quarantine; q == nil || q.ConfirmedBy != "alice" {
+		t.Fatalf("expected confirmed_by alice, got %+v", q)
+	}
+}
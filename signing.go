@@ -0,0 +1,163 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/signing.go
+// HMAC-signed telemetry submissions with timestamp and nonce replay protection
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers of a signed submission.
+const (
+	headerKeyID     = "X-PDM-Key-Id"
+	headerTimestamp = "X-PDM-Timestamp" // Unix seconds
+	headerNonce     = "X-PDM-Nonce"
+	headerSignature = "X-PDM-Signature" // hex HMAC-SHA256 of signedMessage
+)
+
+const noncesFile = "telemetry_nonces.json"
+
+// signedMessage is what a submitter signs: the timestamp, nonce, method and
+// request URI (path and query) on their own lines, then the body. Binding
+// the URI keeps a signature for one pool or quorum source from being
+// accepted by another.
+func signedMessage(timestamp, nonce, method, uri string, body []byte) []byte {
+	msg := []byte(timestamp + "\n" + nonce + "\n" + method + "\n" + uri + "\n")
+	return append(msg, body...)
+}
+
+// signSubmission returns the X-PDM-Signature value for a submission.
+func signSubmission(secret, timestamp, nonce, method, uri string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(signedMessage(timestamp, nonce, method, uri, body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature checks the signature headers of r, whose body has already
+// been read, against cfg and returns the submitter's key id, or an HTTP
+// status and error. The nonce is recorded as used only once everything else
+// checks out.
+func (p *Pool) verifySignature(r *http.Request, body []byte, cfg *SigningConfig, now time.Time) (string, int, error) {
+	id := r.Header.Get(headerKeyID)
+	ts := r.Header.Get(headerTimestamp)
+	nonce := r.Header.Get(headerNonce)
+	sig := r.Header.Get(headerSignature)
+	if id == "" || ts == "" || nonce == "" || sig == "" {
+		return "", http.StatusUnauthorized, fmt.Errorf("signed request required: %s, %s, %s and %s headers", headerKeyID, headerTimestamp, headerNonce, headerSignature)
+	}
+	if len(nonce) > 128 {
+		return "", http.StatusBadRequest, fmt.Errorf("nonce longer than 128 characters")
+	}
+	secret, ok := cfg.Keys[id]
+	if !ok {
+		return "", http.StatusUnauthorized, fmt.Errorf("unknown key id")
+	}
+	want := signSubmission(os.ExpandEnv(secret), ts, nonce, r.Method, r.URL.RequestURI(), body)
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return "", http.StatusUnauthorized, fmt.Errorf("invalid signature")
+	}
+
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", http.StatusBadRequest, fmt.Errorf("%s must be Unix seconds", headerTimestamp)
+	}
+	at := time.Unix(secs, 0)
+	skew := cfg.MaxSkewDuration()
+	if d := now.Sub(at); d > skew || d < -skew {
+		return "", http.StatusUnauthorized, fmt.Errorf("timestamp outside the allowed skew of %s", skew)
+	}
+	if used, err := p.nonces.Use(id+":"+nonce, at, now, skew); err != nil {
+		return "", http.StatusInternalServerError, err
+	} else if used {
+		return "", http.StatusUnauthorized, fmt.Errorf("nonce already used")
+	}
+	return id, http.StatusOK, nil
+}
+
+// nonceCache remembers the nonces of accepted signed requests for as long
+// as their timestamps are within the skew window, which is as long as they
+// could be replayed. Once Load has been called it is persisted to path, so a
+// restart does not reopen the window.
+type nonceCache struct {
+	seen map[string]time.Time // key id ":" nonce -> request timestamp
+	path string
+	mu   sync.Mutex
+}
+
+// Load reads the nonces persisted at path, if any, and persists there from
+// now on.
+func (c *nonceCache) Load(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.path = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &c.seen)
+}
+
+// Use records key, signed at `at`, as used, forgetting keys signed more
+// than skew before now. used reports that key had been used already; err
+// that the cache could not be persisted.
+func (c *nonceCache) Use(key string, at, now time.Time, skew time.Duration) (used bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, dup := c.seen[key]; dup {
+		return true, nil
+	}
+	next := map[string]time.Time{key: at}
+	for k, t := range c.seen {
+		if now.Sub(t) <= skew {
+			next[k] = t
+		}
+	}
+	if c.path != "" {
+		data, err := json.Marshal(next)
+		if err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(c.path+".tmp", data, 0644); err != nil {
+			return false, err
+		}
+		if err := os.Rename(c.path+".tmp", c.path); err != nil {
+			return false, err
+		}
+	}
+	c.seen = next
+	return false, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignedSubmissions(t *testing.T) {
+	const secret = "0123456789abcdef-ops"
+	p := testPool(t)
+	p.Spec.Telemetry.Signing = &SigningConfig{Keys: map[string]string{"ops": secret}, MaxSkew: "5m"}
+	p.open()
+
+	post := func(p *Pool, body, nonce string, at time.Time, key string) *httptest.ResponseRecorder {
+		const uri = "/pdm/v1/pools/test/telemetry"
+		ts := strconv.FormatInt(at.Unix(), 10)
+		r := httptest.NewRequest(http.MethodPost, uri, strings.NewReader(body))
+		r.Header.Set(headerKeyID, "ops")
+		r.Header.Set(headerTimestamp, ts)
+		r.Header.Set(headerNonce, nonce)
+		r.Header.Set(headerSignature, signSubmission(key, ts, nonce, http.MethodPost, uri, []byte(body)))
+		rec := httptest.NewRecorder()
+		p.telemetryHandler(rec, r)
+		return rec
+	}
+	body := `{"oi":1000000,"v":50000}`
+	now := time.Now()
+
+	if rec := post(p, body, "n-1", now, secret); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"submitter":"ops"`) {
+		t.Fatalf("signed submission: %d %s", rec.Code, rec.Body)
+	}
+	date := calculateNextRun(p.Spec.Schedule).Format("2006-01-02")
+	if sub, _ := p.manual.ForDate(date); len(sub.Submitters) != 1 || sub.Submitters[0] != "ops" {
+		t.Fatalf("submitter not recorded: %+v", sub)
+	}
+
+	if rec := post(p, body, "n-1", now, secret); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "nonce already used") {
+		t.Fatalf("replay: expected 401, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(p, body, "n-2", now.Add(-10*time.Minute), secret); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "skew") {
+		t.Fatalf("skewed timestamp: expected 401, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(p, body, "n-3", now, "not-the-secret-at-all"); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "invalid signature") {
+		t.Fatalf("wrong key: expected 401, got %d %s", rec.Code, rec.Body)
+	}
+	unsigned := httptest.NewRecorder()
+	p.telemetryHandler(unsigned, httptest.NewRequest(http.MethodPost, "/api/telemetry", strings.NewReader(body)))
+	if unsigned.Code != http.StatusUnauthorized {
+		t.Fatalf("unsigned: expected 401, got %d", unsigned.Code)
+	}
+
+	// Used nonces survive a restart.
+	restarted := newPool(p.Spec)
+	restarted.open()
+	if rec := post(restarted, body, "n-1", now, secret); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("replay after restart: expected 401, got %d", rec.Code)
+	}
+
+	// The submitter is carried into the step's trace.
+	_, _, info, err := restarted.fetchTelemetryValues(calculateNextRun(p.Spec.Schedule))
+	if err != nil || info == nil || len(info.Submitters) != 1 || info.Submitters[0] != "ops" {
+		t.Fatalf("expected submitters in the trace info, got %+v, %v", info, err)
+	}
+}
+
+func TestNonceCache_ForgetsNoncesOutsideTheWindow(t *testing.T) {
+	var c nonceCache
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if used, _ := c.Use("ops:a", t0, t0, 5*time.Minute); used {
+		t.Fatal("fresh nonce reported as used")
+	}
+	if used, _ := c.Use("ops:b", t0.Add(6*time.Minute), t0.Add(6*time.Minute), 5*time.Minute); used {
+		t.Fatal("fresh nonce reported as used")
+	}
+	if _, ok := c.seen["ops:a"]; ok || len(c.seen) != 1 {
+		t.Fatalf("expected ops:a to be forgotten, have %v", c.seen)
+	}
+}
@@ -1,3 +1,7 @@
 module pdm-simulation
 
 go 1.21
+
+require pdm-personal v0.0.0
+
+replace pdm-personal => ../
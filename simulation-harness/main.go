@@ -1,172 +1,17 @@
 package main
 
 import (
-	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"math"
 	"math/rand"
 	"strings"
 	"time"
-)
-
-// ═══════════════════════════════════════════════════════════════════════
-// EXACT COPY of PDM core from pdm-personal/main.go v1.0.1
-// This is the code under test — unchanged from the repository.
-// ═══════════════════════════════════════════════════════════════════════
-
-type PDMConfig struct {
-	PhiTarget     float64 `json:"phi_target"`
-	BandLow       float64 `json:"band_low"`
-	BandHigh      float64 `json:"band_high"`
-	BurnBase      float64 `json:"burn_base"`
-	BurnVelocityK float64 `json:"burn_velocity_k"`
-	MinS          float64 `json:"min_s"`
-	MinO          float64 `json:"min_o"`
-}
-
-func DefaultConfig(mcap float64) PDMConfig {
-	return PDMConfig{
-		PhiTarget:     0.618,
-		BandLow:       0.60,
-		BandHigh:      0.62,
-		BurnBase:      0.000618,
-		BurnVelocityK: 0.1,
-		MinS:          1e-9 * mcap,
-		MinO:          1e-6,
-	}
-}
-
-func ValidatePDMConfig(cfg PDMConfig, mcap float64) error {
-	if cfg.PhiTarget <= 0 || cfg.PhiTarget >= 1 {
-		return fmt.Errorf("phi_target must be in (0, 1), got %f", cfg.PhiTarget)
-	}
-	if cfg.BandLow <= 0 || cfg.BandHigh <= 0 {
-		return fmt.Errorf("band_low and band_high must be > 0")
-	}
-	if cfg.BandLow >= cfg.BandHigh {
-		return fmt.Errorf("band_low must be < band_high, got %f >= %f", cfg.BandLow, cfg.BandHigh)
-	}
-	if cfg.BandLow > cfg.PhiTarget || cfg.PhiTarget > cfg.BandHigh {
-		return fmt.Errorf("band_low <= phi_target <= band_high required, got %f <= %f <= %f violated", cfg.BandLow, cfg.PhiTarget, cfg.BandHigh)
-	}
-	if mcap <= 0 {
-		return fmt.Errorf("mcap must be > 0")
-	}
-	if cfg.MinS <= 0 {
-		return fmt.Errorf("min_s must be > 0")
-	}
-	if cfg.MinO <= 0 {
-		return fmt.Errorf("min_o must be > 0")
-	}
-	return nil
-}
-
-type StepTrace struct {
-	Timestamp     time.Time `json:"timestamp"`
-	SPrev         float64   `json:"s_prev"`
-	Oi            float64   `json:"o_i"`
-	VTotal        float64   `json:"v_total"`
-	MCap          float64   `json:"m_cap"`
-	PhiTarget     float64   `json:"phi_target"`
-	BandLow       float64   `json:"band_low"`
-	BandHigh      float64   `json:"band_high"`
-	BurnBase      float64   `json:"burn_base"`
-	BurnVelocityK float64   `json:"burn_velocity_k"`
-
-	Velocity   float64 `json:"velocity"`
-	BurnRate   float64 `json:"burn_rate"`
-	BurnAmount float64 `json:"burn_amount"`
-	STemp      float64 `json:"s_temp"`
-	L          float64 `json:"l"`
-	MintRaw    float64 `json:"mint_raw"`
-	MintDamped float64 `json:"mint_damped"`
-	Delta      float64 `json:"delta"`
-	SNew       float64 `json:"s_new"`
-
-	ClampedS      bool   `json:"clamped_s"`
-	ClampedCap    bool   `json:"clamped_cap"`
-	Error         string `json:"error,omitempty"`
-	HashChainRoot string `json:"hash_chain_root"`
-}
-
-func StepPDM(sPrev, oi, vtotal, mcap float64, prevHashChainRoot string, cfg PDMConfig) (float64, StepTrace) {
-	trace := StepTrace{
-		Timestamp:     time.Now().UTC(),
-		SPrev:         sPrev,
-		Oi:            oi,
-		VTotal:        vtotal,
-		MCap:          mcap,
-		PhiTarget:     cfg.PhiTarget,
-		BandLow:       cfg.BandLow,
-		BandHigh:      cfg.BandHigh,
-		BurnBase:      cfg.BurnBase,
-		BurnVelocityK: cfg.BurnVelocityK,
-	}
-
-	if mcap <= 0 {
-		trace.Error = "M_cap must be > 0"
-		return sPrev, trace
-	}
-	if oi < cfg.MinO {
-		oi = cfg.MinO
-		trace.Oi = oi
-	}
-	sSafe := math.Max(sPrev, cfg.MinS)
 
-	velocity := vtotal / sSafe
-	deviation := velocity - cfg.PhiTarget
-	burnRate := 1.0 - cfg.BurnVelocityK*deviation
-	if burnRate < 0 {
-		burnRate = 0
-	}
-	burnAmount := cfg.BurnBase * burnRate * vtotal
-	sTemp := sPrev - burnAmount
-
-	if sTemp < 0 {
-		trace.ClampedS = true
-		sTemp = 0
-	}
-
-	trace.Velocity = velocity
-	trace.BurnRate = burnRate
-	trace.BurnAmount = burnAmount
-	trace.STemp = sTemp
-
-	l := sTemp / oi
-	trace.L = l
-
-	var delta float64
-	if l < cfg.BandLow {
-		mintRaw := cfg.PhiTarget*oi - sTemp
-		if mintRaw < 0 {
-			mintRaw = 0
-		}
-		damping := math.Pow(cfg.PhiTarget, sTemp/mcap)
-		delta = mintRaw * damping
-		trace.MintRaw = mintRaw
-		trace.MintDamped = delta
-	} else if l >= cfg.BandHigh {
-		delta = 0
-	}
-
-	sNew := sTemp + delta
-	if sNew > mcap {
-		trace.ClampedCap = true
-		delta = mcap - sTemp
-		sNew = mcap
-	}
-
-	trace.Delta = delta
-	trace.SNew = sNew
-
-	traceJSON, _ := json.Marshal(trace)
-	h := sha256.New()
-	h.Write([]byte(prevHashChainRoot + string(traceJSON)))
-	trace.HashChainRoot = fmt.Sprintf("%x", h.Sum(nil))
+	"pdm-personal/pdm"
+)
 
-	return sNew, trace
-}
+// The code under test is the pdm package from the repository root, imported
+// directly so the harness always exercises the same StepPDM as the server.
 
 // ═══════════════════════════════════════════════════════════════════════
 // SIMULATION HARNESS
@@ -174,7 +19,7 @@ func StepPDM(sPrev, oi, vtotal, mcap float64, prevHashChainRoot string, cfg PDMC
 
 func main() {
 	mcap := 1000000.0
-	cfg := DefaultConfig(mcap)
+	cfg := pdm.DefaultConfig(mcap)
 
 	fmt.Println("╔══════════════════════════════════════════════════════════════╗")
 	fmt.Println("║   PDM Personal Edition v1.0.1 — Simulation & Verification   ║")
@@ -183,7 +28,7 @@ func main() {
 	fmt.Println()
 
 	// Config validation (Section 3)
-	if err := ValidatePDMConfig(cfg, mcap); err != nil {
+	if err := pdm.ValidatePDMConfig(cfg, mcap); err != nil {
 		fmt.Printf("FAIL: Config validation error: %v\n", err)
 		return
 	}
@@ -211,7 +56,7 @@ func main() {
 	for i := 0; i < 30; i++ {
 		oi := 1000000.0
 		v := 50000.0 + rng.Float64()*10000
-		newS, trace := StepPDM(s, oi, v, mcap, prevHash, cfg)
+		newS, trace := pdm.StepPDM(s, oi, v, mcap, prevHash, cfg)
 
 		status := "STABLE"
 		if trace.L < cfg.BandLow {
@@ -252,7 +97,7 @@ func main() {
 	for i := 0; i < 60; i++ {
 		oi := 2000000.0
 		v := 80000.0
-		newS, trace := StepPDM(s, oi, v, mcap, prevHash, cfg)
+		newS, trace := pdm.StepPDM(s, oi, v, mcap, prevHash, cfg)
 
 		status := "STABLE"
 		if trace.L < cfg.BandLow {
@@ -296,7 +141,7 @@ func main() {
 	monotonic := true
 
 	for _, sLevel := range []float64{100000, 200000, 300000, 400000, 500000, 600000, 700000, 800000, 900000, 950000} {
-		_, trace := StepPDM(sLevel, oiTest, 0, mcap, "", cfg)
+		_, trace := pdm.StepPDM(sLevel, oiTest, 0, mcap, "", cfg)
 
 		lambda := math.Pow(cfg.PhiTarget, sLevel/mcap)
 		ratio := 0.0
@@ -340,7 +185,7 @@ func main() {
 	for i := 0; i < 15; i++ {
 		oi := 50000000.0
 		v := 100.0
-		newS, trace := StepPDM(s, oi, v, mcap, prevHash, cfg)
+		newS, trace := pdm.StepPDM(s, oi, v, mcap, prevHash, cfg)
 
 		capped := ""
 		if trace.ClampedCap {
@@ -384,7 +229,7 @@ func main() {
 	for i := 0; i < 15; i++ {
 		oi := 1000000.0
 		v := 999999999.0
-		newS, trace := StepPDM(s, oi, v, mcap, prevHash, cfg)
+		newS, trace := pdm.StepPDM(s, oi, v, mcap, prevHash, cfg)
 
 		clamped := ""
 		if trace.ClampedS {
@@ -425,7 +270,7 @@ func main() {
 	for _, ratio := range []float64{0.20, 0.40, 0.55, 0.59, 0.60, 0.61, 0.615, 0.618, 0.62, 0.65, 0.80} {
 		oi := 1000000.0
 		sStart := ratio * oi
-		_, trace := StepPDM(sStart, oi, 0, mcap, "", cfg)
+		_, trace := pdm.StepPDM(sStart, oi, 0, mcap, "", cfg)
 
 		band := "BELOW"
 		if trace.L >= cfg.BandHigh {
@@ -464,38 +309,54 @@ func main() {
 	// ─────────────────────────────────────────────────────────────
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("  SIMULATION 7: Hash Chain Integrity (Section 4.7)")
-	fmt.Println("  Run 10 steps, verify each hash chains from the previous")
-	fmt.Println("  Note: timestamps differ per run (time.Now), so we verify")
-	fmt.Println("  chain continuity within a single run, not cross-run replay.")
+	fmt.Println("  Run 10 steps at fixed step times, verify each hash chains from")
+	fmt.Println("  the previous, then replay the same inputs and confirm the")
+	fmt.Println("  chain is reproduced byte for byte (StepPDMAt).")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	s = 618000.0
-	prevHash = ""
+	epoch := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	runChain := func() []string {
+		var roots []string
+		s, prev := 618000.0, ""
+		for i := 0; i < 10; i++ {
+			newS, trace := pdm.StepPDMAt(epoch.AddDate(0, 0, i), s, 1000000, 50000, mcap, prev, cfg)
+			roots = append(roots, trace.HashChainRoot)
+			s = newS
+			prev = trace.HashChainRoot
+		}
+		return roots
+	}
+
 	chainValid := true
+	roots := runChain()
 
 	fmt.Println()
-	for i := 0; i < 10; i++ {
-		newS, trace := StepPDM(s, 1000000, 50000, mcap, prevHash, cfg)
-
-		// Verify: recompute hash from prevHash + trace (minus the hash field itself)
+	for i, root := range roots {
 		// The hash should be non-empty and different from previous
-		if trace.HashChainRoot == "" {
+		if root == "" {
 			chainValid = false
 			fmt.Printf("  ❌ Step %d: empty hash\n", i+1)
+			continue
 		}
-		if i > 0 && trace.HashChainRoot == prevHash {
+		if i > 0 && root == roots[i-1] {
 			chainValid = false
 			fmt.Printf("  ❌ Step %d: hash identical to previous (no chaining)\n", i+1)
 		}
 
-		fmt.Printf("  Step %2d: hash = %s...  (chains from prev ✓)\n", i+1, trace.HashChainRoot[:24])
+		fmt.Printf("  Step %2d: hash = %s...  (chains from prev ✓)\n", i+1, root[:24])
+	}
 
-		s = newS
-		prevHash = trace.HashChainRoot
+	replayed := runChain()
+	for i := range roots {
+		if replayed[i] != roots[i] {
+			chainValid = false
+			fmt.Printf("  ❌ Step %d: replay produced a different hash\n", i+1)
+		}
 	}
 
 	if chainValid {
 		fmt.Println("\n  ✅ Hash chain verified: each step chains from the previous, all unique")
+		fmt.Println("  ✅ Replay reproduced all 10 hashes byte for byte")
 	}
 	fmt.Println()
 
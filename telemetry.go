@@ -26,186 +26,522 @@ Provided "AS IS" without warranty of any kind.
 package main
 
 import (
-	"encoding/csv"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
 	"time"
-)
 
-var manualTelemetry ManualTelemetry
-var csvTelemetry CSVTelemetry
-var webhookTelemetry WebhookTelemetry
+	"pdm-personal/pdm"
+)
 
+// TelemetrySource returns Oi and V for the step on date (YYYY-MM-DD, in the
+// pool's schedule timezone).
 type TelemetrySource interface {
-	FetchOi() (float64, error)
-	FetchV() (float64, error)
+	FetchDate(date string) (float64, float64, error)
 }
 
-func todayYYYYMMDD() string {
-	// Use configured schedule timezone if available; fall back to UTC.
-	if cfgFile != nil && cfgFile.Schedule.Timezone != "" {
-		if loc, err := time.LoadLocation(cfgFile.Schedule.Timezone); err == nil {
-			return time.Now().In(loc).Format("2006-01-02")
-		}
+// ── Submitted Telemetry ────────────────────────────────────────────────
+
+// Submission is one posted (Oi, V) pair and the step date it applies to.
+type Submission struct {
+	Date       string     `json:"date"`
+	Oi         float64    `json:"oi"`
+	V          float64    `json:"v"`
+	ReceivedAt time.Time  `json:"received_at"`
+	ObservedAt *time.Time `json:"observed_at,omitempty"`
+
+	// Accumulated submissions (telemetry.aggregation: accumulate) also keep
+	// the window's running aggregates. V is then the sum of the increments
+	// and Oi, ReceivedAt and ObservedAt those of the latest event.
+	Events  int        `json:"events,omitempty"`
+	OiMax   float64    `json:"oi_max,omitempty"`
+	OiArea  float64    `json:"oi_area,omitempty"` // ∫Oi dt in Oi·seconds, first event to latest
+	FirstAt *time.Time `json:"first_at,omitempty"`
+
+	// Submitters are the key ids that signed the submission, or every event
+	// of an accumulated window.
+	Submitters []string `json:"submitters,omitempty"`
+	// PayloadHash is the SHA-256 of the request body that carried the
+	// submission. For an accumulated window it chains the events' hashes:
+	// SHA-256 of the previous hash followed by the event's.
+	PayloadHash string `json:"payload_sha256,omitempty"`
+}
+
+// provenance describes where the submission came from: its signers, or
+// "api" for unsigned POSTs.
+func (s Submission) provenance(mode string) *pdm.Provenance {
+	source := "api"
+	if len(s.Submitters) > 0 {
+		source = strings.Join(s.Submitters, ",")
 	}
-	return time.Now().UTC().Format("2006-01-02")
+	received := s.ReceivedAt
+	return &pdm.Provenance{Mode: mode, Source: source, SubmittedAt: &received, PayloadHash: s.PayloadHash}
 }
 
-// ── Manual Telemetry ───────────────────────────────────────────────────
+// payloadHash returns the hex SHA-256 of b.
+func payloadHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
 
-type ManualTelemetry struct {
-	latestOi float64
-	latestV  float64
-	mu       sync.RWMutex
+// AsOf is when the values were measured: the submitter's observed_at if
+// given, otherwise the receive time.
+func (s Submission) AsOf() time.Time {
+	if s.ObservedAt != nil {
+		return *s.ObservedAt
+	}
+	return s.ReceivedAt
+}
+
+const submissionsFile = "telemetry_submissions.json"
+
+// accumulate folds the event ev into s, the window so far. Events are taken
+// in arrival order; an event stamped before the latest one adds no time to
+// the Oi integral.
+func (s Submission) accumulate(ev Submission) Submission {
+	t := ev.AsOf()
+	if s.Events == 0 {
+		ev.Events, ev.OiMax, ev.FirstAt = 1, ev.Oi, &t
+		return ev
+	}
+	if d := t.Sub(s.AsOf()); d > 0 {
+		s.OiArea += s.Oi * d.Seconds()
+	}
+	s.Events++
+	s.V += ev.V
+	s.Oi = ev.Oi
+	if ev.Oi > s.OiMax {
+		s.OiMax = ev.Oi
+	}
+	s.ReceivedAt, s.ObservedAt = ev.ReceivedAt, ev.ObservedAt
+	for _, id := range ev.Submitters {
+		if !containsString(s.Submitters, id) {
+			s.Submitters = append(s.Submitters, id)
+		}
+	}
+	if ev.PayloadHash != "" {
+		s.PayloadHash = payloadHash([]byte(s.PayloadHash + ev.PayloadHash))
+	}
+	return s
 }
 
-func (m *ManualTelemetry) FetchOi() (float64, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.latestOi, nil
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
 }
 
-func (m *ManualTelemetry) FetchV() (float64, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.latestV, nil
+// Aggregate summarises an accumulated window for the step at `at`, deriving
+// Oi by gauge ("last", "max" or "twa").
+func (s Submission) Aggregate(at time.Time, gauge string) pdm.Aggregation {
+	twa := s.Oi
+	if s.FirstAt != nil {
+		area, span := s.OiArea, s.AsOf().Sub(*s.FirstAt)
+		if d := at.Sub(s.AsOf()); d > 0 {
+			area += s.Oi * d.Seconds()
+			span += d
+		}
+		if span > 0 {
+			twa = area / span.Seconds()
+		}
+	}
+	agg := pdm.Aggregation{Events: s.Events, OiGauge: gauge, OiLast: s.Oi, OiMax: s.OiMax, OiTWA: twa, VSum: s.V, Last: s.AsOf()}
+	if s.FirstAt != nil {
+		agg.First = *s.FirstAt
+	}
+	return agg
 }
 
-// ── CSV Telemetry ──────────────────────────────────────────────────────
+// gaugeValue returns the Oi that agg's gauge selects.
+func gaugeValue(agg pdm.Aggregation) float64 {
+	switch agg.OiGauge {
+	case "max":
+		return agg.OiMax
+	case "twa":
+		return agg.OiTWA
+	}
+	return agg.OiLast
+}
 
-type CSVTelemetry struct {
-	csvPath string
+// submissions holds posted telemetry keyed by target date, so a step only
+// ever uses values submitted for its own date. A later submission for the
+// same date replaces the earlier one. Once Load has been called every change
+// is written to path before it takes effect, so accepted submissions survive
+// a restart.
+type submissions struct {
+	byDate map[string]Submission
+	path   string
+	mu     sync.RWMutex
 }
 
-// FetchToday reads the CSV once and returns both Oi and V for "today".
-func (c *CSVTelemetry) FetchToday() (float64, float64, error) {
-	f, err := os.Open(c.csvPath)
+// Load reads the submissions persisted at path, if any, and persists there
+// from now on.
+func (s *submissions) Load(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
 	if err != nil {
-		return 0, 0, err
+		return err
+	}
+	var list []Submission
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	s.byDate = make(map[string]Submission, len(list))
+	for _, sub := range list {
+		s.byDate[sub.Date] = sub
 	}
-	defer f.Close()
+	return nil
+}
 
-	reader := csv.NewReader(f)
-	records, err := reader.ReadAll()
-	if err != nil {
-		return 0, 0, err
+func (s *submissions) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.byDate)
+}
+
+func (s *submissions) Submit(sub Submission) error {
+	_, err := s.update(sub.Date, func(Submission) Submission { return sub })
+	return err
+}
+
+// SubmitAll stores several submissions at once, each replacing any earlier
+// one for its date.
+func (s *submissions) SubmitAll(subs []Submission) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := make(map[string]Submission, len(s.byDate)+len(subs))
+	for d, v := range s.byDate {
+		next[d] = v
 	}
-	if len(records) < 2 {
-		return 0, 0, fmt.Errorf("CSV needs header + data")
+	for _, sub := range subs {
+		next[sub.Date] = sub
 	}
+	if err := s.save(next); err != nil {
+		return err
+	}
+	s.byDate = next
+	return nil
+}
 
-	today := todayYYYYMMDD()
+// Accumulate adds the event ev to the window for its date and returns the
+// window.
+func (s *submissions) Accumulate(ev Submission) (Submission, error) {
+	return s.update(ev.Date, func(cur Submission) Submission { return cur.accumulate(ev) })
+}
 
-	// Allow minor CSV formatting issues (whitespace, BOM) and tolerate common date formats.
-	matchDate := func(raw string) bool {
-		ds := strings.TrimSpace(raw)
-		ds = strings.TrimPrefix(ds, "\ufeff") // handle UTF-8 BOM if present
-		if ds == today {
-			return true
-		}
-		layouts := []string{"2006-01-02", "2006/01/02"}
-		for _, layout := range layouts {
-			if t, err := time.Parse(layout, ds); err == nil {
-				if t.Format("2006-01-02") == today {
-					return true
-				}
-			}
-		}
-		return false
+// update replaces the submission for date with fn of the current one (the
+// zero Submission if none) and persists the result before it takes effect.
+func (s *submissions) update(date string, fn func(Submission) Submission) (Submission, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := make(map[string]Submission, len(s.byDate)+1)
+	for d, v := range s.byDate {
+		next[d] = v
+	}
+	sub := fn(next[date])
+	next[date] = sub
+	if err := s.save(next); err != nil {
+		return Submission{}, err
 	}
+	s.byDate = next
+	return sub, nil
+}
 
-	for _, row := range records[1:] {
-		if len(row) < 3 {
-			continue
-		}
-		if !matchDate(row[0]) {
-			continue
-		}
+func (s *submissions) ForDate(date string) (Submission, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sub, ok := s.byDate[date]
+	return sub, ok
+}
 
-		oi, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
-		if err != nil {
-			return 0, 0, fmt.Errorf("CSV Oi parse error: %v", err)
-		}
-		v, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
-		if err != nil {
-			return 0, 0, fmt.Errorf("CSV V parse error: %v", err)
+// Prune drops submissions for dates before date.
+func (s *submissions) Prune(date string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := make(map[string]Submission, len(s.byDate))
+	for d, v := range s.byDate {
+		if d >= date {
+			next[d] = v
 		}
+	}
+	if len(next) == len(s.byDate) {
+		return nil
+	}
+	if err := s.save(next); err != nil {
+		return err
+	}
+	s.byDate = next
+	return nil
+}
 
-		// Enforce the same constraints as the POST endpoint for parity across telemetry modes.
-		if oi <= 0 {
-			return 0, 0, fmt.Errorf("CSV Oi must be > 0")
-		}
-		if v < 0 {
-			return 0, 0, fmt.Errorf("CSV V must be >= 0")
-		}
+// save atomically writes byDate to s.path, synced before the rename so the
+// file is never torn. Callers hold s.mu.
+func (s *submissions) save(byDate map[string]Submission) error {
+	if s.path == "" {
+		return nil
+	}
+	list := make([]Submission, 0, len(byDate))
+	for _, sub := range byDate {
+		list = append(list, sub)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Date < list[j].Date })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
 
-		return oi, v, nil
+func (s *submissions) FetchDate(date string) (float64, float64, error) {
+	sub, ok := s.ForDate(date)
+	if !ok {
+		return 0, 0, fmt.Errorf("no telemetry submitted for %s", date)
 	}
-	return 0, 0, fmt.Errorf("no data for today (%s)", today)
+	return sub.Oi, sub.V, nil
 }
 
-func (c *CSVTelemetry) FetchOi() (float64, error) {
-	oi, _, err := c.FetchToday()
-	return oi, err
+// FetchFresh returns the submission for the step at `at` on date, rejecting
+// one measured more than maxAge before it. A zero maxAge disables the check.
+// For an accumulated window the latest event is what must be fresh.
+func (s *submissions) FetchFresh(date string, at time.Time, maxAge time.Duration) (Submission, error) {
+	sub, ok := s.ForDate(date)
+	if !ok {
+		return Submission{}, fmt.Errorf("no telemetry submitted for %s", date)
+	}
+	if age := at.Sub(sub.AsOf()); maxAge > 0 && age > maxAge {
+		return Submission{}, fmt.Errorf("telemetry for %s is stale: measured %s, %v before the step (max_age %v)",
+			date, sub.AsOf().Format(time.RFC3339), age.Round(time.Minute), maxAge)
+	}
+	return sub, nil
 }
 
-func (c *CSVTelemetry) FetchV() (float64, error) {
-	_, v, err := c.FetchToday()
-	return v, err
+// ── Manual Telemetry ───────────────────────────────────────────────────
+
+type ManualTelemetry struct {
+	submissions
 }
 
 // ── Webhook Telemetry ──────────────────────────────────────────────────
 
 type WebhookTelemetry struct {
-	latestOi float64
-	latestV  float64
-	mu       sync.RWMutex
+	submissions
 }
 
-func (w *WebhookTelemetry) FetchOi() (float64, error) {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-	return w.latestOi, nil
+// ── Source State ───────────────────────────────────────────────────────
+
+// telemetrySource is the runtime state of one configured source: the pool's
+// own, or one member of a quorum. Only the part matching the source's mode
+// is used.
+type telemetrySource struct {
+	manual  ManualTelemetry
+	csv     CSVTelemetry
+	webhook WebhookTelemetry
+	http    *HTTPTelemetry
+	prom    *PrometheusTelemetry
 }
 
-func (w *WebhookTelemetry) FetchV() (float64, error) {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-	return w.latestV, nil
+func (s *telemetrySource) init(cfg TelemetryConfig, loc *time.Location) {
+	s.csv = CSVTelemetry{csvPath: cfg.CSVPath, loc: loc, cols: cfg.CSVColumns, layout: cfg.CSVDateLayout}
 }
 
-func (w *WebhookTelemetry) Update(oi, v float64) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-	w.latestOi = oi
-	w.latestV = v
+// open builds the pull clients and loads persisted submissions from
+// submissionsPath.
+func (s *telemetrySource) open(cfg TelemetryConfig, submissionsPath string) error {
+	var err error
+	switch cfg.Mode {
+	case "http":
+		s.http, err = newHTTPTelemetry(*cfg.HTTP)
+	case "prometheus":
+		s.prom, err = newPrometheusTelemetry(*cfg.Prometheus)
+	}
+	if err != nil {
+		return err
+	}
+	if st := s.store(cfg.Mode); st != nil {
+		if err := st.Load(submissionsPath); err != nil {
+			return fmt.Errorf("submissions: %v", err)
+		}
+	}
+	return nil
 }
 
-// ── HTTP Handler ───────────────────────────────────────────────────────
+// store returns the source's posted telemetry, or nil for the file and pull
+// modes.
+func (s *telemetrySource) store(mode string) *submissions {
+	switch mode {
+	case "manual":
+		return &s.manual.submissions
+	case "webhook":
+		return &s.webhook.submissions
+	}
+	return nil
+}
 
-// telemetryHandler accepts POST requests to update telemetry (manual/webhook modes)
-func telemetryHandler(w http.ResponseWriter, r *http.Request) {
-	// Optional shared-secret auth (recommended if server is network-exposed)
-	if cfgFile != nil && cfgFile.Telemetry.AuthToken != "" {
-		tok := r.Header.Get("X-PDM-Token")
-		if tok == "" {
-			// allow Authorization: Bearer <token>
-			const pfx = "Bearer "
-			authz := r.Header.Get("Authorization")
-			if len(authz) > len(pfx) && authz[:len(pfx)] == pfx {
-				tok = authz[len(pfx):]
-			}
-		}
-		if tok != cfgFile.Telemetry.AuthToken {
-			writeJSONError(w, http.StatusUnauthorized, "unauthorized")
-			return
+// fetch returns the source's Oi and V for the step at `at` on date, with
+// their provenance.
+func (s *telemetrySource) fetch(cfg TelemetryConfig, date string, at time.Time) (oi, v float64, info *pdm.TelemetryInfo, err error) {
+	info = &pdm.TelemetryInfo{}
+	switch cfg.Mode {
+	case "manual", "webhook":
+		var sub Submission
+		sub, err = s.store(cfg.Mode).FetchFresh(date, at, cfg.MaxAgeDuration())
+		oi, v = sub.Oi, sub.V
+		if err == nil && cfg.Aggregation == "accumulate" {
+			agg := sub.Aggregate(at, cfg.OiGauge)
+			oi = gaugeValue(agg)
+			info.Aggregation = &agg
 		}
+		info.Submitters = sub.Submitters
+		info.Provenance = sub.provenance(cfg.Mode)
+	case "csv":
+		oi, v, info.Provenance, err = s.csv.Fetch(date)
+	case "http":
+		oi, v, info.Provenance, err = s.http.Fetch(date)
+	case "prometheus":
+		oi, v, info, err = s.prom.FetchAt(at)
+	default:
+		err = fmt.Errorf("unknown telemetry mode %q", cfg.Mode)
+	}
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	info.Provenance.OiRaw, info.Provenance.VRaw = oi, v
+	return oi, v, info, nil
+}
+
+// ── HTTP Handler ───────────────────────────────────────────────────────
+
+// telemetryHandler accepts POST requests to update the pool's telemetry
+// (manual/webhook modes, or in quorum mode the submitter named by ?source=)
+func (p *Pool) telemetryHandler(w http.ResponseWriter, r *http.Request) {
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	w = sw
+	defer func() { p.metrics.responded(sw.status) }()
+
+	// The payload is tiny.
+	store, cfg, body, submitter, ok := p.readSubmission(w, r, 8*1024)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Oi         float64    `json:"oi"`
+		V          float64    `json:"v"`
+		Date       string     `json:"date"`
+		ObservedAt *time.Time `json:"observed_at"`
+	}
+	if err := json.Unmarshal(body, &input); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	if input.Oi <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "Oi must be > 0")
+		return
+	}
+	if input.V < 0 {
+		writeJSONError(w, http.StatusBadRequest, "V must be >= 0")
+		return
+	}
+
+	sub, status, err := p.newSubmission(input.Oi, input.V, input.Date, input.ObservedAt, cfg.MaxAgeDuration(), time.Now())
+	if err != nil {
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	if submitter != "" {
+		sub.Submitters = []string{submitter}
+	}
+	sub.PayloadHash = payloadHash(body)
+
+	resp := map[string]interface{}{
+		"status":      "received",
+		"oi":          sub.Oi,
+		"v":           sub.V,
+		"date":        sub.Date,
+		"received_at": sub.ReceivedAt.Format(time.RFC3339),
+		"timestamp":   sub.ReceivedAt.Format(time.RFC3339),
+	}
+	if cfg.Aggregation == "accumulate" {
+		var window Submission
+		window, err = store.Accumulate(sub)
+		resp["events"], resp["v_sum"], resp["oi_max"] = window.Events, window.V, window.OiMax
+	} else {
+		err = store.Submit(sub)
+	}
+	if err != nil {
+		p.log.Printf("Telemetry submission write error: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to store telemetry")
+		return
+	}
+	if submitter != "" {
+		resp["submitter"] = submitter
+		p.log.Printf("Telemetry for %s accepted from %s", sub.Date, submitter)
+	}
+	if source := r.URL.Query().Get("source"); source != "" {
+		resp["source"] = source
+	}
+	p.metrics.submitted(1)
+	p.events.publish(eventTelemetry, resp)
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// readSubmission authorises a telemetry POST and reads its body, of at most
+// limit bytes. It resolves the store and settings the POST feeds (see
+// submissionTarget) and, for signed requests, the signer's key id. On
+// failure it has written the error response and ok is false.
+func (p *Pool) readSubmission(w http.ResponseWriter, r *http.Request, limit int64) (store *submissions, cfg TelemetryConfig, body []byte, submitter string, ok bool) {
+	store, cfg, terr := p.submissionTarget(r.URL.Query().Get("source"))
+	if op := p.Spec.Operator.AuthToken; op != "" && subtle.ConstantTimeCompare([]byte(requestToken(r)), []byte(op)) == 1 {
+		writeJSONError(w, http.StatusForbidden, "the operator credential cannot submit telemetry")
+		return
+	}
+	token, signing := p.Spec.Telemetry.AuthToken, p.Spec.Telemetry.Signing
+	if cfg.AuthToken != "" {
+		token = cfg.AuthToken
+	}
+	if cfg.Signing != nil {
+		signing = cfg.Signing
+	}
+	// Signed requests are checked once the body has been read.
+	if signing == nil && !checkAuth(w, r, token) {
+		return
 	}
 
 	if r.Method != http.MethodPost {
@@ -213,13 +549,13 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if telemetryMode == "csv" {
-		writeJSONError(w, http.StatusMethodNotAllowed, "POST disabled in csv mode")
+	if store == nil {
+		writeJSONError(w, http.StatusMethodNotAllowed, terr.Error())
 		return
 	}
 
-	// Limit request body size (the payload is tiny). Helps avoid resource exhaustion if exposed on a network.
-	r.Body = http.MaxBytesReader(w, r.Body, 8*1024)
+	// Limit request body size. Helps avoid resource exhaustion if exposed on a network.
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		// If MaxBytesReader is tripped, the error string typically contains "request body too large".
@@ -231,40 +567,208 @@ func telemetryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input struct {
-		Oi float64 `json:"oi"`
-		V  float64 `json:"v"`
+	if signing != nil {
+		var status int
+		if submitter, status, err = p.verifySignature(r, body, signing, time.Now()); err != nil {
+			if status == http.StatusInternalServerError {
+				p.log.Printf("Telemetry nonce write error: %v", err)
+				err = fmt.Errorf("failed to record nonce")
+			}
+			writeJSONError(w, status, err.Error())
+			return
+		}
 	}
-	if err := json.Unmarshal(body, &input); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+	return store, cfg, body, submitter, true
+}
+
+// readOperatorRequest authorises an operator action (confirming quarantined
+// values, scheduling parameter changes) and reads its body, of at most limit
+// bytes. Unlike telemetry, these actions are refused outright unless the
+// pool has an operator credential, and telemetry credentials never pass:
+// the request must carry operator.auth_token and, if operator.signing is
+// set, be signed with one of its keys. operator is the signing key id. On
+// failure it has written the error response and ok is false.
+func (p *Pool) readOperatorRequest(w http.ResponseWriter, r *http.Request, limit int64) (body []byte, operator string, ok bool) {
+	token, signing := p.Spec.Operator.AuthToken, p.Spec.Operator.Signing
+	if token == "" && signing == nil {
+		writeJSONError(w, http.StatusForbidden, "operator actions require operator.auth_token or operator.signing to be configured")
 		return
 	}
-
-	if input.Oi <= 0 {
-		writeJSONError(w, http.StatusBadRequest, "Oi must be > 0")
+	if tok := requestToken(r); tok != "" && p.isTelemetryToken(tok) {
+		writeJSONError(w, http.StatusForbidden, "telemetry credentials cannot authorise operator actions")
 		return
 	}
-	if input.V < 0 {
-		writeJSONError(w, http.StatusBadRequest, "V must be >= 0")
+	if token != "" && !checkAuth(w, r, token) {
 		return
 	}
 
-	switch telemetryMode {
-	case "manual":
-		manualTelemetry.mu.Lock()
-		manualTelemetry.latestOi = input.Oi
-		manualTelemetry.latestV = input.V
-		manualTelemetry.mu.Unlock()
-	case "webhook":
-		webhookTelemetry.Update(input.Oi, input.V)
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	if signing != nil {
+		var status int
+		if operator, status, err = p.verifySignature(r, body, signing, time.Now()); err != nil {
+			if status == http.StatusInternalServerError {
+				p.log.Printf("Operator nonce write error: %v", err)
+				err = fmt.Errorf("failed to record nonce")
+			}
+			writeJSONError(w, status, err.Error())
+			return
+		}
+	}
+	return body, operator, true
+}
+
+// submitted returns the pool's store of posted telemetry, or nil for the
+// file, pull and quorum modes.
+func (p *Pool) submitted() *submissions {
+	return p.store(p.Spec.Telemetry.Mode)
+}
+
+// stores returns every store of posted telemetry the pool has: its own, or
+// those of its quorum's submitters.
+func (p *Pool) stores() []*submissions {
+	var out []*submissions
+	if s := p.submitted(); s != nil {
+		out = append(out, s)
+	}
+	for _, m := range p.quorum {
+		if s := m.store(m.cfg.Mode); s != nil {
+			out = append(out, s)
+		}
 	}
+	return out
+}
+
+// submissionTarget returns the store and settings a POST feeds: the pool's
+// own, or the quorum submitter named source. The store is nil, with the
+// reason, when the pool does not accept POSTs there.
+func (p *Pool) submissionTarget(source string) (*submissions, TelemetryConfig, error) {
+	if p.Spec.Telemetry.Mode != "quorum" {
+		if s := p.submitted(); s != nil {
+			return s, p.Spec.Telemetry, nil
+		}
+		return nil, TelemetryConfig{}, fmt.Errorf("POST disabled in %s mode", p.Spec.Telemetry.Mode)
+	}
+	for _, m := range p.quorum {
+		if m.name == source {
+			if s := m.store(m.cfg.Mode); s != nil {
+				return s, m.cfg, nil
+			}
+			return nil, TelemetryConfig{}, fmt.Errorf("quorum source %q is %s mode and does not accept POSTs", source, m.cfg.Mode)
+		}
+	}
+	return nil, TelemetryConfig{}, fmt.Errorf("quorum mode: ?source= must name a manual or webhook source")
+}
+
+// newSubmission stamps a posted (Oi, V) pair with its receive time and target
+// date. date defaults to the date of the pool's next scheduled step; an
+// explicit date must be YYYY-MM-DD, later than the last recorded step or
+// skip, and not before genesis.
+// On error it also returns the HTTP status to answer with.
+func (p *Pool) newSubmission(oi, v float64, date string, observedAt *time.Time, maxAge time.Duration, now time.Time) (Submission, int, error) {
+	loc := scheduleLocation(p.Spec.Schedule)
+	if date == "" {
+		date = calculateNextRun(p.Spec.Schedule).In(loc).Format("2006-01-02")
+	} else if _, err := time.ParseInLocation("2006-01-02", date, loc); err != nil {
+		return Submission{}, http.StatusBadRequest, fmt.Errorf("date must be YYYY-MM-DD")
+	}
+
+	if err := p.checkSubmissionDate(date); err != nil {
+		return Submission{}, http.StatusConflict, err
+	}
+
+	if observedAt != nil {
+		if observedAt.After(now) {
+			return Submission{}, http.StatusBadRequest, fmt.Errorf("observed_at is in the future")
+		}
+		if maxAge > 0 && now.Sub(*observedAt) > maxAge {
+			return Submission{}, http.StatusBadRequest, fmt.Errorf("observed_at is older than telemetry.max_age (%v)", maxAge)
+		}
+		t := observedAt.UTC()
+		observedAt = &t
+	}
+
+	return Submission{Date: date, Oi: oi, V: v, ReceivedAt: now.UTC(), ObservedAt: observedAt}, 0, nil
+}
+
+// checkSubmissionDate reports why telemetry for date (YYYY-MM-DD) can no
+// longer be used: its step is already recorded, or it precedes the pool's
+// genesis.
+func (p *Pool) checkSubmissionDate(date string) error {
+	loc := scheduleLocation(p.Spec.Schedule)
+	p.mu.RLock()
+	recorded := len(p.state.Journal) > 0
+	last := p.lastScheduledTime()
+	p.mu.RUnlock()
+	if recorded && date <= last.In(loc).Format("2006-01-02") {
+		return fmt.Errorf("a step for %s is already recorded", date)
+	}
+	// genesisTime is a day before the date's slot, which must follow last.
+	if at, err := genesisTime(p.Spec.Schedule, date); err == nil && !last.IsZero() && !at.AddDate(0, 0, 1).After(last) {
+		return fmt.Errorf("%s is before the pool's genesis", date)
+	}
+	return nil
+}
+
+// checkAuth enforces the pool's optional shared secret (telemetry.auth_token,
+// recommended if the server is network-exposed). It writes a 401 and returns
+// false when the request is not authorised.
+func checkAuth(w http.ResponseWriter, r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	if subtle.ConstantTimeCompare([]byte(requestToken(r)), []byte(token)) != 1 {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return false
+	}
+	return true
+}
+
+// requestToken returns the token a request carries in X-PDM-Token or as
+// Authorization: Bearer <token>.
+func requestToken(r *http.Request) string {
+	tok := r.Header.Get("X-PDM-Token")
+	if tok == "" {
+		// allow Authorization: Bearer <token>
+		const pfx = "Bearer "
+		authz := r.Header.Get("Authorization")
+		if len(authz) > len(pfx) && authz[:len(pfx)] == pfx {
+			tok = authz[len(pfx):]
+		}
+	}
+	return tok
+}
+
+// isTelemetryToken reports whether tok is the pool's telemetry.auth_token or
+// a quorum source's.
+func (p *Pool) isTelemetryToken(tok string) bool {
+	t := p.Spec.Telemetry
+	tokens := []string{t.AuthToken}
+	if t.Quorum != nil {
+		for _, src := range t.Quorum.Sources {
+			tokens = append(tokens, src.AuthToken)
+		}
+	}
+	for _, want := range tokens {
+		if want != "" && subtle.ConstantTimeCompare([]byte(tok), []byte(want)) == 1 {
+			return true
+		}
+	}
+	return false
+}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"status":    "received",
-		"oi":        input.Oi,
-		"v":         input.V,
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	})
+// isFinite reports whether f is neither NaN nor an infinity.
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
 }
 
 func writeJSON(w http.ResponseWriter, status int, v interface{}) {
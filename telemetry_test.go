@@ -0,0 +1,199 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+func TestRunScheduled_TelemetryFailurePolicies(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 3, n, 0, 0, 0, 0, time.UTC) }
+
+	// skip: no telemetry received → explicit skipped entry, supply unchanged.
+	p := testPoolWithGenesis(t, day(1))
+	p.Spec.Telemetry.OnFailure = "skip"
+	p.runScheduled(day(2))
+	if j := p.state.Journal; len(j) != 1 || j[0].Skipped == nil || j[0].Skipped.Policy != pdm.SkipPolicyTelemetrySkip {
+		t.Fatalf("skip: expected one telemetry_skip entry, got %+v", j)
+	}
+
+	// A non-finite value is a failure, not a step that cannot be journaled.
+	p = testPoolWithGenesis(t, day(1))
+	p.Spec.Telemetry.OnFailure = "skip"
+	p.manual.Submit(Submission{Date: "2026-03-02", Oi: math.NaN(), V: 50000, ReceivedAt: day(1)})
+	p.runScheduled(day(2))
+	if j := p.state.Journal; len(j) != 1 || j[0].Skipped == nil || !strings.Contains(j[0].Skipped.Reason, "finite") {
+		t.Fatalf("NaN: expected one telemetry_skip entry, got %+v", j)
+	}
+
+	// hold: a good step, then a failure reuses its Oi and V.
+	p = testPoolWithGenesis(t, day(1))
+	p.Spec.Telemetry.OnFailure = "hold"
+	p.manual.Submit(Submission{Date: "2026-03-02", Oi: 1000000, V: 50000, ReceivedAt: day(1)})
+	p.runScheduled(day(2))
+	p.runScheduled(day(3))
+	h := p.state.History
+	if len(h) != 2 || h[1].Oi != 1000000 || h[1].VTotal != 50000 || h[1].Telemetry == nil || !h[1].Telemetry.HeldFrom.Equal(day(2)) {
+		t.Fatalf("hold: expected second step on held values, got %+v", h)
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, p.state.Journal); !rep.Valid {
+		t.Fatalf("hold: annotated step failed audit: %+v", rep)
+	}
+
+	// abort: skip is recorded and the pool halts.
+	p = testPoolWithGenesis(t, day(1))
+	p.Spec.Telemetry.OnFailure = "abort"
+	p.runScheduled(day(2))
+	if !p.isHalted() || len(p.state.Journal) != 1 || p.state.Journal[0].Skipped.Policy != pdm.SkipPolicyTelemetryAbort {
+		t.Fatalf("abort: expected halted pool with telemetry_abort entry, halted=%q", p.halted)
+	}
+}
+
+func TestFetchTelemetryValues_PerDateAndMaxAge(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 3, n, 0, 0, 0, 0, time.UTC) }
+	p := testPoolWithGenesis(t, day(1))
+	p.manual.Submit(Submission{Date: "2026-03-02", Oi: 1000000, V: 50000, ReceivedAt: day(1)})
+
+	if _, v, _, err := p.fetchTelemetryValues(day(2)); err != nil || v != 50000 {
+		t.Fatalf("expected the 2026-03-02 submission, got v=%v err=%v", v, err)
+	}
+	// Last week's values are not reused for a later date.
+	if _, _, _, err := p.fetchTelemetryValues(day(3)); err == nil {
+		t.Fatal("expected no telemetry for 2026-03-03")
+	}
+
+	p.Spec.Telemetry.MaxAge = "12h"
+	if _, _, _, err := p.fetchTelemetryValues(day(2)); err == nil || !strings.Contains(err.Error(), "stale") {
+		t.Fatalf("expected stale rejection, got %v", err)
+	}
+	observed := day(2).Add(-time.Hour)
+	p.manual.Submit(Submission{Date: "2026-03-02", Oi: 1000000, V: 70000, ReceivedAt: day(1), ObservedAt: &observed})
+	if _, v, _, err := p.fetchTelemetryValues(day(2)); err != nil || v != 70000 {
+		t.Fatalf("expected observed_at to govern freshness, got v=%v err=%v", v, err)
+	}
+}
+
+func TestTelemetryHandler_SubmissionDate(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 3, n, 0, 0, 0, 0, time.UTC) }
+	p := testPoolWithGenesis(t, day(1))
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		p.telemetryHandler(rec, httptest.NewRequest(http.MethodPost, "/api/telemetry", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := post(`{"oi":1000000,"v":50000,"date":"2026-03-02"}`); rec.Code != http.StatusOK {
+		t.Fatalf("explicit date: %d %s", rec.Code, rec.Body)
+	}
+	if sub, ok := p.manual.ForDate("2026-03-02"); !ok || sub.V != 50000 || sub.ReceivedAt.IsZero() {
+		t.Fatalf("submission not stored under its date: %+v", sub)
+	}
+	if rec := post(`{"oi":1000000,"v":1,"date":"03/02/2026"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad date: expected 400, got %d", rec.Code)
+	}
+
+	p.runScheduled(day(2))
+	if rec := post(`{"oi":1000000,"v":1,"date":"2026-03-02"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("date already stepped: expected 409, got %d", rec.Code)
+	}
+
+	p.Spec.Telemetry.MaxAge = "1h"
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	if rec := post(`{"oi":1000000,"v":1,"observed_at":"` + old + `"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("stale observed_at: expected 400, got %d", rec.Code)
+	}
+}
+
+func TestSubmissions_SurviveRestart(t *testing.T) {
+	p := testPool(t)
+	p.open()
+	date := calculateNextRun(p.Spec.Schedule).Format("2006-01-02")
+	rec := httptest.NewRecorder()
+	p.telemetryHandler(rec, httptest.NewRequest(http.MethodPost, "/api/telemetry", strings.NewReader(`{"oi":1000000,"v":50000}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("submit: %d %s", rec.Code, rec.Body)
+	}
+
+	restarted := newPool(p.Spec)
+	restarted.open()
+	if sub, ok := restarted.manual.ForDate(date); !ok || sub.V != 50000 {
+		t.Fatalf("submission for %s lost across restart: %+v", date, sub)
+	}
+
+	// Consumed dates are pruned from disk as well.
+	restarted.manual.Submit(Submission{Date: "2000-01-01", Oi: 1, V: 1, ReceivedAt: time.Now()})
+	restarted.manual.Prune(date)
+	again := newPool(p.Spec)
+	again.open()
+	if _, ok := again.manual.ForDate("2000-01-01"); ok || again.manual.Len() != 1 {
+		t.Fatalf("expected only %s after prune, have %d", date, again.manual.Len())
+	}
+}
+
+func TestAccumulate_SumsVAndGaugesOi(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 3, n, 0, 0, 0, 0, time.UTC) }
+	p := testPoolWithGenesis(t, day(1))
+	p.Spec.Telemetry.Aggregation = "accumulate"
+	p.Spec.Telemetry.OiGauge = "twa"
+
+	// Oi 1000 for 6h, 2000 for 6h, then 1200 for the last 12h: TWA 1350.
+	for _, ev := range []struct {
+		hour  int
+		oi, v float64
+	}{{0, 1000, 10}, {6, 2000, 20}, {12, 1200, 30}} {
+		at := time.Date(2026, 3, 1, ev.hour, 0, 0, 0, time.UTC)
+		p.manual.Accumulate(Submission{Date: "2026-03-02", Oi: ev.oi, V: ev.v, ReceivedAt: at})
+	}
+
+	oi, v, info, err := p.fetchTelemetryValues(day(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 60 || oi != 1350 || info == nil || info.Aggregation.Events != 3 || info.Aggregation.OiMax != 2000 {
+		t.Fatalf("unexpected aggregate: oi=%v v=%v info=%+v", oi, v, info.Aggregation)
+	}
+
+	p.runScheduled(day(2))
+	h := p.state.History
+	if len(h) != 1 || h[0].Telemetry == nil || h[0].Telemetry.Aggregation.Events != 3 {
+		t.Fatalf("aggregation not recorded in the trace: %+v", h)
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, p.state.Journal); !rep.Valid {
+		t.Fatalf("aggregated step failed audit: %+v", rep)
+	}
+
+	// The window resets: the next step has no events until new ones arrive.
+	if _, _, _, err := p.fetchTelemetryValues(day(3)); err == nil {
+		t.Fatal("expected an empty window after the step")
+	}
+}
+
+func TestStep_RecordsProvenance(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, 3, n, 0, 0, 0, 0, time.UTC) }
+	p := testPoolWithGenesis(t, day(1))
+	body := `{"oi":0.0000001,"v":50000,"date":"2026-03-02"}`
+	rec := httptest.NewRecorder()
+	p.telemetryHandler(rec, httptest.NewRequest(http.MethodPost, "/api/telemetry", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("submit: %d %s", rec.Code, rec.Body)
+	}
+	p.runScheduled(day(2))
+
+	tr := p.state.History[0]
+	prov := tr.Telemetry.Provenance
+	if prov == nil || prov.Mode != "manual" || prov.Source != "api" || prov.PayloadHash != payloadHash([]byte(body)) || prov.SubmittedAt == nil {
+		t.Fatalf("unexpected provenance: %+v", prov)
+	}
+	// The raw Oi survives the MinO floor.
+	if prov.OiRaw != 0.0000001 || tr.Oi != p.state.Config.MinO || prov.VRaw != 50000 {
+		t.Fatalf("expected raw Oi 1e-7 and effective %g, got raw %g effective %g", p.state.Config.MinO, prov.OiRaw, tr.Oi)
+	}
+	if rep := pdm.AuditFromGenesis(*p.genesis, p.state.Journal); !rep.Valid {
+		t.Fatalf("step with provenance failed audit: %+v", rep)
+	}
+}
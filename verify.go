@@ -0,0 +1,331 @@
+/*
+Progressive Depletion Minting (PDM)
+Reference Implementation – Personal Edition
+
+Author: Valraj Singh Mann
+Framework: Mann Mechanics
+
+This file forms part of a reference implementation of
+Progressive Depletion Minting (PDM).
+
+This code is provided for educational, research, and
+non-commercial demonstration purposes only.
+
+Commercial use, production deployment, or claims of
+certification or compliance are prohibited without
+explicit written licence from the rights holder.
+
+Patent protections may apply regardless of software licence.
+
+Provided "AS IS" without warranty of any kind.
+*/
+
+// pdm-personal/verify.go
+// Offline audit subcommand: pdm-personal verify <journal.jsonl|state.json|history.csv|bundle.json>
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"pdm-personal/pdm"
+)
+
+// auditInput is a trace history loaded from any supported file format.
+type auditInput struct {
+	Format  string
+	Genesis *pdm.Genesis
+	Anchor  string
+	Config  *pdm.PDMConfig
+	MCap    float64
+	Entries []pdm.Entry
+}
+
+// runVerify implements `pdm-personal verify`. It returns the process exit
+// code: 0 when every check passes, 1 on tampering, recomputation mismatch or
+// a theorem violation, 2 on usage or read errors.
+func runVerify(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	anchor := fs.String("anchor", "", "override the chain root preceding the first trace")
+	mcap := fs.Float64("mcap", 0, "capacity M for history.csv checks (csv has no m_cap column)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: pdm-personal verify [-anchor root] [-mcap M] [-json] <journal.jsonl|state.json|history.csv|bundle.json|traces.json>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	in, err := loadAuditInput(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "verify: %v\n", err)
+		return 2
+	}
+	if *anchor != "" {
+		in.Anchor = *anchor
+	}
+	if *mcap > 0 {
+		in.MCap = *mcap
+	}
+
+	if in.Format == "history.csv" {
+		return reportCSV(path, in, stdout)
+	}
+
+	// A state.json or bundle config is the one in force now; it only
+	// describes the start of the chain if no parameter change intervened.
+	initial := in.Config
+	for _, e := range in.Entries {
+		if e.ParamChange != nil {
+			initial = nil
+			break
+		}
+	}
+
+	var report pdm.AuditReport
+	if in.Genesis != nil && *anchor == "" {
+		report = pdm.AuditFromGenesis(*in.Genesis, in.Entries)
+	} else {
+		report = pdm.AuditEntries(in.Entries, in.Anchor, initial)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printAuditReport(stdout, path, in, report)
+	}
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
+
+func loadAuditInput(path string) (*auditInput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+
+	switch {
+	case strings.EqualFold(filepath.Ext(path), ".jsonl"):
+		entries, err := readJournal(path)
+		if err != nil {
+			return nil, err
+		}
+		return &auditInput{Format: "journal", Genesis: siblingGenesis(path), Entries: entries}, nil
+	case strings.EqualFold(filepath.Ext(path), ".csv"):
+		return loadHistoryCSV(trimmed)
+	case trimmed[0] == '[':
+		var entries []pdm.Entry
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("parse trace array: %v", err)
+		}
+		return &auditInput{Format: "traces", Entries: entries}, nil
+	case trimmed[0] == '{':
+		var probe map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return nil, fmt.Errorf("parse JSON: %v", err)
+		}
+		if _, ok := probe["traces"]; ok {
+			var b pdm.Bundle
+			if err := json.Unmarshal(trimmed, &b); err != nil {
+				return nil, fmt.Errorf("parse bundle: %v", err)
+			}
+			return &auditInput{Format: "bundle", Genesis: b.Genesis, Anchor: b.AnchorRoot, Config: &b.Config, MCap: b.MCap, Entries: b.Traces}, nil
+		}
+		var st PoolState
+		if err := json.Unmarshal(trimmed, &st); err != nil {
+			return nil, fmt.Errorf("parse state: %v", err)
+		}
+		in := &auditInput{Format: "state.json", Anchor: st.HistoryBaseRoot, Config: &st.Config, MCap: st.MCap, Entries: pdm.StepEntries(st.History)}
+		if st.GenesisRoot != "" {
+			in.Genesis = siblingGenesis(path)
+		}
+		if len(st.History) == 0 && st.JournalEntries > 0 {
+			// Snapshot-only state.json: the traces live in the journal beside it.
+			entries, err := readJournal(filepath.Join(filepath.Dir(path), journalFile))
+			if err != nil {
+				return nil, fmt.Errorf("state.json has no history and its journal is unreadable: %v", err)
+			}
+			in.Format = "state.json + " + journalFile
+			in.Entries = entries
+		}
+		return in, nil
+	}
+	return nil, fmt.Errorf("%s: unrecognised format (expected journal.jsonl, state.json, bundle, trace array or history.csv)", path)
+}
+
+// siblingGenesis loads genesis.json from the same directory as path, if
+// present. A genesis that fails to parse is still returned when possible so
+// that the audit reports its broken seal.
+func siblingGenesis(path string) *pdm.Genesis {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), genesisFile))
+	if err != nil {
+		return nil
+	}
+	var g pdm.Genesis
+	if json.Unmarshal(data, &g) != nil {
+		return nil
+	}
+	return &g
+}
+
+func printAuditReport(w io.Writer, path string, in *auditInput, r pdm.AuditReport) {
+	mark := func(ok bool) string {
+		if ok {
+			return "PASS"
+		}
+		return "FAIL"
+	}
+
+	fmt.Fprintf(w, "PDM audit of %s (%s, pdm core %s)\n", path, in.Format, pdm.Version)
+	fmt.Fprintf(w, "  steps:          %d\n", r.Chain.Steps)
+	if r.Chain.ParamChanges > 0 {
+		fmt.Fprintf(w, "  param changes:  %d\n", r.Chain.ParamChanges)
+	}
+	if r.Chain.Skipped > 0 {
+		fmt.Fprintf(w, "  skipped steps:  %d\n", r.Chain.Skipped)
+	}
+	if g := r.Genesis; g != nil {
+		fmt.Fprintf(w, "  genesis:        %s (pool %q, created %s)\n", g.HashChainRoot, g.PoolName, g.CreatedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "  anchor root:    %q\n", r.Chain.AnchorRoot)
+	fmt.Fprintf(w, "  head root:      %s\n", r.Chain.HeadRoot)
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "[%s] hash chain       %d/%d links verified\n", mark(r.Chain.BrokenLinks == 0), r.Chain.LinksVerified, r.Chain.Entries)
+	if b := r.Chain.FirstBreak; b != nil {
+		fmt.Fprintf(w, "       first break at entry %d (%s)\n", b.Index, b.Timestamp.Format(time.RFC3339))
+		fmt.Fprintf(w, "       expected %s\n       actual   %s\n", b.Expected, b.Actual)
+	}
+	fmt.Fprintf(w, "[%s] accounting       %d violations\n", mark(len(r.Chain.AccountingErrors) == 0), len(r.Chain.AccountingErrors))
+	for _, e := range r.Chain.AccountingErrors {
+		fmt.Fprintf(w, "       step %d %s: expected %f, got %f\n", e.Index, e.Field, e.Expected, e.Actual)
+	}
+	fmt.Fprintf(w, "[%s] control-law replay %d steps, %d mismatched fields\n", mark(len(r.ReplayMismatches) == 0), r.StepsReplayed, len(r.ReplayMismatches))
+	for _, m := range r.ReplayMismatches {
+		fmt.Fprintf(w, "       step %d %s: recorded %s, replayed %s\n", m.Index, m.Field, m.Recorded, m.Replayed)
+	}
+	fmt.Fprintf(w, "[%s] invariants       %d violations\n", mark(len(r.Violations) == 0), len(r.Violations))
+	for _, v := range r.Violations {
+		fmt.Fprintf(w, "       step %d %s: %s\n", v.Index, v.Rule, v.Detail)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "RESULT: %s\n", mark(r.Valid))
+}
+
+// ── history.csv ────────────────────────────────────────────────────────
+
+// loadHistoryCSV reads the legacy history.csv export. It carries no hash or
+// burn fields, so only bounds and continuity can be checked.
+func loadHistoryCSV(data []byte) (*auditInput, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse history.csv: %v", err)
+	}
+	if len(records) < 1 {
+		return nil, fmt.Errorf("history.csv needs a header")
+	}
+	col := map[string]int{}
+	for i, h := range records[0] {
+		col[strings.TrimSpace(h)] = i
+	}
+	for _, name := range []string{"timestamp", "s_prev", "s_new"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("history.csv missing column %q", name)
+		}
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	num := func(row []string, name string) float64 {
+		f, _ := strconv.ParseFloat(field(row, name), 64)
+		return f
+	}
+
+	var traces []pdm.StepTrace
+	for n, row := range records[1:] {
+		ts, err := time.Parse("2006-01-02 15:04:05", field(row, "timestamp"))
+		if err != nil {
+			return nil, fmt.Errorf("history.csv row %d: bad timestamp %q", n+2, field(row, "timestamp"))
+		}
+		traces = append(traces, pdm.StepTrace{
+			Timestamp:  ts.UTC(),
+			Oi:         num(row, "oi"),
+			VTotal:     num(row, "v_total"),
+			SPrev:      num(row, "s_prev"),
+			SNew:       num(row, "s_new"),
+			L:          num(row, "l_ratio"),
+			ClampedS:   field(row, "clamped_s") == "true",
+			ClampedCap: field(row, "clamped_cap") == "true",
+			Error:      field(row, "error"),
+		})
+	}
+	return &auditInput{Format: "history.csv", Entries: pdm.StepEntries(traces)}, nil
+}
+
+func reportCSV(path string, in *auditInput, w io.Writer) int {
+	failures := 0
+	traces := pdm.Steps(in.Entries)
+	fmt.Fprintf(w, "PDM audit of %s (history.csv, pdm core %s)\n", path, pdm.Version)
+	fmt.Fprintf(w, "  steps:          %d\n\n", len(traces))
+	fmt.Fprintln(w, "[SKIP] hash chain       history.csv has no hash_chain_root; audit state.json or an export bundle instead")
+	fmt.Fprintln(w, "[SKIP] control-law replay history.csv has no burn or mint fields")
+
+	// CSV values are rounded to 6 decimals, so continuity allows for that.
+	for i, tr := range traces {
+		if tr.Error != "" {
+			continue
+		}
+		if tr.SNew < 0 {
+			failures++
+			fmt.Fprintf(w, "[FAIL] step %d theorem1_non_negativity: s_new %f < 0\n", i, tr.SNew)
+		}
+		if in.MCap > 0 && tr.SNew > in.MCap+1e-6 {
+			failures++
+			fmt.Fprintf(w, "[FAIL] step %d theorem2_capacity: s_new %f exceeds m_cap %f\n", i, tr.SNew, in.MCap)
+		}
+		if i > 0 && traces[i-1].Error == "" && math.Abs(traces[i-1].SNew-tr.SPrev) > 2e-6 {
+			failures++
+			fmt.Fprintf(w, "[FAIL] step %d continuity: s_prev %f does not match previous s_new %f\n", i, tr.SPrev, traces[i-1].SNew)
+		}
+	}
+	if in.MCap <= 0 {
+		fmt.Fprintln(w, "[SKIP] theorem2_capacity  pass -mcap to check S <= M")
+	}
+
+	fmt.Fprintln(w)
+	if failures > 0 {
+		fmt.Fprintf(w, "RESULT: FAIL (%d violations)\n", failures)
+		return 1
+	}
+	fmt.Fprintln(w, "RESULT: PASS (bounds and continuity only)")
+	return 0
+}